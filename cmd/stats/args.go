@@ -9,13 +9,36 @@ import (
 	"github.com/jessevdk/go-flags"
 	"github.com/squizzling/stats/internal/emitters/diskfree"
 
+	"github.com/squizzling/stats/internal/backoff"
+	"github.com/squizzling/stats/internal/check"
 	"github.com/squizzling/stats/internal/emitters/blockstat"
 	"github.com/squizzling/stats/internal/emitters/bucketstat"
+	"github.com/squizzling/stats/internal/emitters/exec"
+	"github.com/squizzling/stats/internal/emitters/ipmi"
+	"github.com/squizzling/stats/internal/emitters/loadavg"
+	"github.com/squizzling/stats/internal/emitters/meminfo"
+	"github.com/squizzling/stats/internal/emitters/netstat"
 	"github.com/squizzling/stats/internal/emitters/procnetdev"
+	"github.com/squizzling/stats/internal/emitters/procstat"
+	"github.com/squizzling/stats/internal/emitters/psi"
+	"github.com/squizzling/stats/internal/emitters/smart"
+	"github.com/squizzling/stats/internal/emitters/sockstat"
+	"github.com/squizzling/stats/internal/emitters/statsdlistener"
+	"github.com/squizzling/stats/internal/emitters/textfile"
+	"github.com/squizzling/stats/internal/emitters/vmstat"
+	"github.com/squizzling/stats/internal/history"
+	"github.com/squizzling/stats/internal/istats"
+	"github.com/squizzling/stats/internal/statsd"
+)
+
+const (
+	modeRun   = ""
+	modeCheck = "check"
+	modeTop   = "top"
 )
 
 type Opts struct {
-	Target    string             `short:"t" long:"target"                   description:"target statsd address" `
+	Target    string             `short:"t" long:"target"                   description:"target statsd address, host[:port] or scheme://address" `
 	Host      *string            `          long:"host"                     description:"local hostname"        `
 	List      bool               `short:"l" long:"list"                     description:"List emitters"         `
 	Disable   func(string) error `short:"d" long:"disable"                  description:"Disable emitter"       `
@@ -23,11 +46,39 @@ type Opts struct {
 	Interval  time.Duration      `short:"i" long:"interval" default:"1s"    description:"send interval"         `
 	Verbose   bool               `short:"v" long:"verbose"                  description:"Enable verbose logging"`
 	FakeStats bool               `short:"f" long:"fake-stats"               description:"Log stats only"        `
+
+	TagFormat     string        `long:"tag-format"      default:"datadog" description:"tag format sent to the target, one of datadog, influxdb, or graphite"`
+	MaxPacketSize int           `long:"max-packet-size" default:"1432"    description:"maximum size of a packet sent to the target"`
+	FlushPeriod   time.Duration `long:"flush-period"    default:"1s"      description:"maximum time metrics are buffered before they are sent"`
+
 	procnetdev.ProcNetDevOpts
+	procstat.ProcStatOpts
 	blockstat.BlockStatOpts
 	bucketstat.BucketStatOpts
 	diskfree.DiskFreeOpts
+	exec.ExecOpts
+	ipmi.IPMIOpts
+	loadavg.LoadAvgOpts
+	meminfo.MemInfoOpts
+	netstat.NetStatOpts
+	psi.PSIOpts
+	smart.SmartOpts
+	sockstat.SockStatOpts
+	vmstat.VmStatOpts
+	textfile.TextFileOpts
+	statsdlistener.StatsdListenerOpts
+	check.CheckOpts
+	istats.CardinalityOpts
+	istats.PoolOpts
+	statsd.SpoolOpts
+	history.HistoryOpts
+	backoff.BackoffOpts
+
+	targetNetwork string
+	targetAddress string
+	tagFormat     statsd.TagFormat
 
+	mode        string
 	positional  []string
 	haveEnable  bool
 	haveDisable bool
@@ -52,12 +103,38 @@ func (opts *Opts) Get(name string) interface{} {
 	switch name {
 	case "procnetdev":
 		return &opts.ProcNetDevOpts
+	case "procstat":
+		return &opts.ProcStatOpts
 	case "blockstat":
 		return &opts.BlockStatOpts
 	case "bucketstat":
 		return &opts.BucketStatOpts
 	case "diskfree":
 		return &opts.DiskFreeOpts
+	case "exec":
+		return &opts.ExecOpts
+	case "ipmi":
+		return &opts.IPMIOpts
+	case "loadavg":
+		return &opts.LoadAvgOpts
+	case "meminfo":
+		return &opts.MemInfoOpts
+	case "netstat":
+		return &opts.NetStatOpts
+	case "psi":
+		return &opts.PSIOpts
+	case "smart":
+		return &opts.SmartOpts
+	case "sockstat":
+		return &opts.SockStatOpts
+	case "vmstat":
+		return &opts.VmStatOpts
+	case "textfile":
+		return &opts.TextFileOpts
+	case "statsd":
+		return &opts.StatsdListenerOpts
+	case "backoff":
+		return &opts.BackoffOpts
 	default:
 		return nil
 	}
@@ -66,8 +143,23 @@ func (opts *Opts) Get(name string) interface{} {
 func (opts *Opts) Validate() []string {
 	var errors []string
 
-	if len(opts.positional) != 0 {
-		errors = append(errors, "no positional arguments are allowed")
+	if len(opts.positional) > 0 {
+		switch opts.positional[0] {
+		case modeCheck, modeTop:
+			opts.mode = opts.positional[0]
+		default:
+			errors = append(errors, fmt.Sprintf("unrecognized mode %s", opts.positional[0]))
+		}
+	}
+
+	if len(opts.positional) > 1 {
+		errors = append(errors, "only a single mode is allowed")
+	}
+
+	if opts.mode == modeCheck && len(opts.Checks) == 0 {
+		errors = append(errors, "check mode requires at least one check.warning or check.critical")
+	} else if opts.mode != modeCheck && len(opts.Checks) != 0 {
+		errors = append(errors, "check.warning and check.critical are only valid in check mode")
 	}
 
 	if opts.haveEnable && opts.haveDisable {
@@ -83,16 +175,30 @@ func (opts *Opts) Validate() []string {
 		}
 	}
 
-	if !opts.FakeStats {
+	if !opts.FakeStats && opts.mode == modeRun {
 		if opts.Target == "" {
 			errors = append(errors, "target is required when fake-stats is not enabled")
 		}
 
-		if strings.IndexByte(opts.Target, ':') == -1 {
-			opts.Target = opts.Target + ":8125"
+		var err error
+		if opts.targetNetwork, opts.targetAddress, err = statsd.ParseTarget(opts.Target); err != nil {
+			errors = append(errors, err.Error())
+		} else if opts.SpoolOpts.Directory != "" && !statsd.IsStream(opts.targetNetwork) {
+			errors = append(errors, "spool.directory requires a tcp or unixstream target")
 		}
 	}
 
+	var err error
+	if opts.tagFormat, err = statsd.ParseTagFormat(opts.TagFormat); err != nil {
+		errors = append(errors, err.Error())
+	}
+	if opts.MaxPacketSize < 64 {
+		errors = append(errors, "max-packet-size must be at least 64")
+	}
+	if opts.FlushPeriod <= 0 {
+		errors = append(errors, "flush-period must be positive")
+	}
+
 	return errors
 }
 
@@ -104,6 +210,7 @@ func parseArgs(args []string) *Opts {
 	opts.Disable = funcMakeEnableDisable(opts, false)
 
 	parser := flags.NewParser(opts, flags.HelpFlag|flags.PassDoubleDash)
+	parser.Usage = "[OPTIONS] [check|top]"
 	positional, err := parser.ParseArgs(args)
 	if err != nil {
 		if !isHelp(err) {
@@ -118,11 +225,29 @@ func parseArgs(args []string) *Opts {
 
 	var errors []string
 
+	errors = append(errors, opts.CheckOpts.Validate()...)
 	errors = append(errors, opts.Validate()...)
+	errors = append(errors, opts.CardinalityOpts.Validate()...)
+	errors = append(errors, opts.PoolOpts.Validate()...)
+	errors = append(errors, opts.SpoolOpts.Validate()...)
+	errors = append(errors, opts.HistoryOpts.Validate()...)
+	errors = append(errors, opts.BackoffOpts.Validate()...)
 	errors = append(errors, opts.ProcNetDevOpts.Validate()...)
+	errors = append(errors, opts.ProcStatOpts.Validate()...)
 	errors = append(errors, opts.BlockStatOpts.Validate()...)
 	errors = append(errors, opts.BucketStatOpts.Validate()...)
 	errors = append(errors, opts.DiskFreeOpts.Validate()...)
+	errors = append(errors, opts.ExecOpts.Validate()...)
+	errors = append(errors, opts.IPMIOpts.Validate()...)
+	errors = append(errors, opts.LoadAvgOpts.Validate()...)
+	errors = append(errors, opts.MemInfoOpts.Validate()...)
+	errors = append(errors, opts.NetStatOpts.Validate()...)
+	errors = append(errors, opts.PSIOpts.Validate()...)
+	errors = append(errors, opts.SmartOpts.Validate()...)
+	errors = append(errors, opts.SockStatOpts.Validate()...)
+	errors = append(errors, opts.VmStatOpts.Validate()...)
+	errors = append(errors, opts.TextFileOpts.Validate()...)
+	errors = append(errors, opts.StatsdListenerOpts.Validate()...)
 
 	if len(errors) > 0 {
 		parser.WriteHelp(os.Stderr)
@@ -130,12 +255,21 @@ func parseArgs(args []string) *Opts {
 		for _, err := range errors {
 			_, _ = fmt.Fprintf(os.Stderr, "error parsing command line: %s\n", err)
 		}
-		os.Exit(1)
+		os.Exit(opts.failureExitCode())
 	}
 
 	return opts
 }
 
+// failureExitCode is the exit code for invalid arguments, which is UNKNOWN
+// in check mode so it isn't mistaken for a warning.
+func (opts *Opts) failureExitCode() int {
+	if opts.mode == modeCheck {
+		return int(check.StatusUnknown)
+	}
+	return 1
+}
+
 // isHelp is a helper to test the error from ParseArgs() to
 // determine if the help message was written. It is safe to
 // call without first checking that error is nil.
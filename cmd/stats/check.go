@@ -0,0 +1,39 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/squizzling/stats/internal/check"
+	"github.com/squizzling/stats/internal/istats"
+	"github.com/squizzling/stats/pkg/emitter"
+)
+
+// runCheck collects from every emitter once, and reports the result of the
+// checks in the Nagios plugin format.
+func runCheck(emitters []emitter.TickEmitter, memoryPool *istats.MemoryPool, opts *check.CheckOpts) check.Status {
+	tick := time.Now()
+	ctx, cancel := context.WithTimeout(context.Background(), opts.Timeout)
+	defer cancel()
+
+	done := make(chan struct{})
+	go func() {
+		for _, e := range emitters {
+			e.EmitTick(ctx, tick)
+		}
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(opts.Timeout):
+		fmt.Printf("STATS %s - collection timed out after %s\n", check.StatusUnknown, opts.Timeout)
+		return check.StatusUnknown
+	}
+
+	report := check.Evaluate(opts.Checks, memoryPool.Drain())
+	report.Write(os.Stdout)
+	return report.Status
+}
@@ -1,42 +1,61 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"os"
+	"os/signal"
+	"syscall"
 	"time"
 
-	"github.com/alexcesaro/statsd"
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
 
 	_ "github.com/squizzling/stats/internal/emitters/blockstat"
 	_ "github.com/squizzling/stats/internal/emitters/bucketstat"
 	_ "github.com/squizzling/stats/internal/emitters/diskfree"
+	_ "github.com/squizzling/stats/internal/emitters/exec"
 	_ "github.com/squizzling/stats/internal/emitters/ipmi"
+	_ "github.com/squizzling/stats/internal/emitters/loadavg"
 	_ "github.com/squizzling/stats/internal/emitters/meminfo"
+	_ "github.com/squizzling/stats/internal/emitters/netstat"
 	_ "github.com/squizzling/stats/internal/emitters/pmbus"
 	_ "github.com/squizzling/stats/internal/emitters/procnetdev"
 	_ "github.com/squizzling/stats/internal/emitters/procstat"
+	_ "github.com/squizzling/stats/internal/emitters/psi"
 	_ "github.com/squizzling/stats/internal/emitters/smart"
+	_ "github.com/squizzling/stats/internal/emitters/sockstat"
+	_ "github.com/squizzling/stats/internal/emitters/statsdlistener"
 	_ "github.com/squizzling/stats/internal/emitters/sysfs"
 	_ "github.com/squizzling/stats/internal/emitters/systemd"
+	_ "github.com/squizzling/stats/internal/emitters/textfile"
+	_ "github.com/squizzling/stats/internal/emitters/vmstat"
 	_ "github.com/squizzling/stats/internal/emitters/zfs"
 
+	"github.com/squizzling/stats/internal/history"
 	"github.com/squizzling/stats/internal/istats"
+	"github.com/squizzling/stats/internal/sdnotify"
+	"github.com/squizzling/stats/internal/statsd"
 	"github.com/squizzling/stats/internal/ticker"
 	"github.com/squizzling/stats/pkg/emitter"
 	"github.com/squizzling/stats/pkg/sources"
 	"github.com/squizzling/stats/pkg/statser"
 )
 
-func createLogger(verbose bool) *zap.Logger {
+func createLogger(verbose bool, quiet bool) *zap.Logger {
 	cfg := zap.NewDevelopmentConfig()
 	cfg.OutputPaths = []string{"stdout"}
 	cfg.ErrorOutputPaths = []string{"stdout"}
 	cfg.DisableStacktrace = true
+	if quiet {
+		// stdout belongs to the mode, and only problems are worth reporting
+		cfg.OutputPaths = []string{"stderr"}
+		cfg.ErrorOutputPaths = []string{"stderr"}
+		cfg.Level.SetLevel(zapcore.WarnLevel)
+	}
 	if verbose {
 		cfg.Level.SetLevel(zapcore.DebugLevel)
-	} else {
+	} else if !quiet {
 		cfg.Level.SetLevel(zapcore.InfoLevel)
 	}
 	logger, err := cfg.Build()
@@ -47,13 +66,18 @@ func createLogger(verbose bool) *zap.Logger {
 	return logger
 }
 
-func createStatsClient(logger *zap.Logger, target string) *statsd.Client {
-	c, err := statsd.New(
-		statsd.Address(target),
-		statsd.Network("udp4"),
-		statsd.FlushPeriod(1*time.Second),
-		statsd.TagsFormat(statsd.Datadog),
-	)
+func createStatsClient(logger *zap.Logger, opts *Opts) *statsd.Client {
+	c, err := statsd.New(&statsd.Config{
+		Network:       opts.targetNetwork,
+		Address:       opts.targetAddress,
+		TagFormat:     opts.tagFormat,
+		MaxPacketSize: opts.MaxPacketSize,
+		FlushPeriod:   opts.FlushPeriod,
+		ErrorHandler: func(err error) {
+			logger.Warn("statsd client error", zap.Error(err))
+		},
+		Spool: &opts.SpoolOpts,
+	})
 	if err != nil {
 		logger.Error(
 			"failed to create statsd client",
@@ -65,6 +89,58 @@ func createStatsClient(logger *zap.Logger, target string) *statsd.Client {
 	return c
 }
 
+// startHistory records everything sent to statsPool in a history store, and
+// serves it over HTTP.  It returns the pool wrapping statsPool.
+func startHistory(logger *zap.Logger, statsPool statser.Pool, opts *history.HistoryOpts) (*history.Store, statser.Pool) {
+	store := history.NewStore(opts)
+	if opts.File != "" {
+		if err := store.Load(opts.File); err != nil {
+			logger.Warn("failed to load history", zap.String("file", opts.File), zap.Error(err))
+		}
+	}
+	go func() {
+		err := history.NewServer(logger, store).ListenAndServe(opts.Listen)
+		logger.Error("history server failed", zap.String("listen", opts.Listen), zap.Error(err))
+	}()
+	logger.Info("serving history", zap.String("listen", opts.Listen))
+	return store, history.NewPool(store, statsPool)
+}
+
+// reportHistory writes the self-metrics for the history, and saves it if it
+// is due.
+func reportHistory(logger *zap.Logger, statsPool statser.Pool, store *history.Store, opts *history.HistoryOpts, lastSave *time.Time) {
+	seriesCount, dropped, evicted := store.Stats()
+	s := statsPool.Host()
+	s.Gauge("stats.history.series", seriesCount)
+	s.Count("stats.history.dropped", dropped)
+	s.Count("stats.history.evicted", evicted)
+
+	if opts.File != "" && time.Since(*lastSave) >= opts.SaveInterval {
+		*lastSave = time.Now()
+		saveHistory(logger, store, opts)
+	}
+}
+
+// saveHistory saves the history, if it has a file.
+func saveHistory(logger *zap.Logger, store *history.Store, opts *history.HistoryOpts) {
+	if opts.File == "" {
+		return
+	}
+	if err := store.Save(opts.File); err != nil {
+		logger.Warn("failed to save history", zap.String("file", opts.File), zap.Error(err))
+	}
+}
+
+// reportSpool writes the self-metrics for the spool.
+func reportSpool(statsPool statser.Pool, c *statsd.Client) {
+	ss := c.SpoolStats()
+	s := statsPool.Host()
+	s.Count("stats.spool.spooled", ss.Spooled)
+	s.Count("stats.spool.replayed", ss.Replayed)
+	s.Count("stats.spool.dropped", ss.Dropped)
+	s.Gauge("stats.spool.bytes", ss.Bytes)
+}
+
 func main() {
 	opts := parseArgs(os.Args[1:])
 
@@ -75,21 +151,37 @@ func main() {
 		return
 	}
 
-	logger := createLogger(opts.Verbose)
+	logger := createLogger(opts.Verbose, opts.mode != modeRun)
 	defer func() {
 		_ = logger.Sync()
 	}()
 
 	var statsPool statser.Pool
-	if opts.FakeStats {
+	var memoryPool *istats.MemoryPool
+	var clientPool *istats.Pool
+	var statsClient *statsd.Client
+	if opts.mode != modeRun {
+		memoryPool = istats.NewMemoryPool(*opts.Host)
+		statsPool = memoryPool
+	} else if opts.FakeStats {
 		statsPool = istats.NewFakePool(*opts.Host)
 		logger.Info("using logging statser")
 	} else {
-		statsPool = istats.NewPool(*opts.Host, createStatsClient(logger, opts.Target))
-		logger.Info("using statser", zap.String("target", opts.Target))
+		statsClient = createStatsClient(logger, opts)
+		clientPool = istats.NewPool(*opts.Host, statsClient, &opts.PoolOpts)
+		statsPool = clientPool
+		logger.Info("using statser", zap.String("network", opts.targetNetwork), zap.String("address", opts.targetAddress))
+	}
+
+	var historyStore *history.Store
+	if opts.mode == modeRun && opts.HistoryOpts.Listen != "" {
+		historyStore, statsPool = startHistory(logger, statsPool, &opts.HistoryOpts)
 	}
+	lastHistorySave := time.Now()
 
-	var emitters []emitter.Emitter
+	var emitters []emitter.TickEmitter
+	var limitPools []*istats.LimitPool
+	var topEmitters []*topEmitter
 	for key, factory := range sources.Sources {
 		if opts.haveEnable || opts.haveDisable {
 			_, ok := opts.selected[key]
@@ -100,11 +192,27 @@ func main() {
 		}
 		logger.Info("enabled", zap.String("emitter", key))
 
-		e := factory(logger, statsPool, opts)
+		emitterPool := statsPool
+		if opts.mode == modeTop {
+			// Each emitter gets its own pool, so the view can be grouped by emitter.
+			memoryPool = istats.NewMemoryPool(*opts.Host)
+			emitterPool = memoryPool
+		}
+		limitPool := istats.NewLimitPool(logger, emitterPool, key, &opts.CardinalityOpts)
+		e := createEmitter(logger, key, factory, limitPool, opts)
 		if e == nil {
 			logger.Error("emitter creation failed", zap.String("emitter", key))
 		} else {
-			emitters = append(emitters, e)
+			te := newRecoveringEmitter(logger, key, e)
+			emitters = append(emitters, te)
+			limitPools = append(limitPools, limitPool)
+			if opts.mode == modeTop {
+				topEmitters = append(topEmitters, &topEmitter{
+					name:       key,
+					emitter:    te,
+					memoryPool: memoryPool,
+				})
+			}
 		}
 	}
 
@@ -112,11 +220,74 @@ func main() {
 		logger.Warn("unrecognized emitter", zap.String("emitter", key))
 	}
 
+	if opts.mode == modeCheck {
+		status := runCheck(emitters, memoryPool, &opts.CheckOpts)
+		_ = logger.Sync()
+		os.Exit(int(status))
+	}
+
+	if opts.mode == modeTop {
+		if err := runTop(topEmitters, opts.Interval); err != nil {
+			logger.Error("top failed", zap.Error(err))
+			_ = logger.Sync()
+			os.Exit(1)
+		}
+		return
+	}
+
+	notifier := sdnotify.New()
+	if watchdog, ok := sdnotify.WatchdogInterval(); ok && opts.Interval >= watchdog/2 {
+		logger.Warn("interval is too long for the watchdog", zap.Duration("interval", opts.Interval), zap.Duration("watchdog", watchdog))
+	}
+	if err := notifier.Ready(fmt.Sprintf("%d emitters enabled", len(emitters))); err != nil {
+		logger.Warn("failed to notify service manager", zap.Error(err))
+	}
+
+	// Stopping is handled between ticks, so the history is saved without
+	// racing an emitter, and a restart loses nothing since the last tick.
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGTERM, syscall.SIGINT)
+
 	tckr := ticker.NewAlignedTicker(opts.Interval, 1*time.Second)
-	for range tckr.C {
-		logger.Info("emitting")
+	for {
+		var tick time.Time
+		select {
+		case tick = <-tckr.C:
+		case sig := <-signals:
+			logger.Info("stopping", zap.Stringer("signal", sig))
+			if historyStore != nil {
+				saveHistory(logger, historyStore, &opts.HistoryOpts)
+			}
+			_ = logger.Sync()
+			return
+		}
+
+		logger.Info("emitting", zap.Time("tick", tick))
+		start := time.Now()
+		ctx, cancel := context.WithDeadline(context.Background(), tick.Add(opts.Interval))
 		for _, e := range emitters {
-			e.Emit()
+			e.EmitTick(ctx, tick)
+		}
+		cancel()
+
+		// The watchdog is only fed once every emitter has finished, so a hung
+		// emitter gets the service restarted.
+		status := fmt.Sprintf("%d emitters enabled, last tick took %s", len(emitters), time.Since(start).Round(time.Millisecond))
+		if err := notifier.Watchdog(status); err != nil {
+			logger.Warn("failed to notify service manager", zap.Error(err))
+		}
+
+		for _, lp := range limitPools {
+			lp.Report()
+		}
+		if clientPool != nil {
+			clientPool.Expire()
+		}
+		if opts.SpoolOpts.Directory != "" && statsClient != nil {
+			reportSpool(statsPool, statsClient)
+		}
+		if historyStore != nil {
+			reportHistory(logger, statsPool, historyStore, &opts.HistoryOpts, &lastHistorySave)
 		}
 	}
 }
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"runtime/debug"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/squizzling/stats/pkg/emitter"
+	"github.com/squizzling/stats/pkg/statser"
+)
+
+// createEmitter calls factory, treating a panic as a failure to create the
+// emitter rather than a reason to stop the process.
+func createEmitter(logger *zap.Logger, name string, factory emitter.EmitterFactory, statsPool statser.Pool, opts emitter.OptProvider) (e emitter.Emitter) {
+	defer func() {
+		if r := recover(); r != nil {
+			logger.Error("emitter creation panicked", zap.String("emitter", name), zap.String("panic", fmt.Sprint(r)), zap.ByteString("stack", debug.Stack()))
+			e = nil
+		}
+	}()
+	return factory(logger, statsPool, opts)
+}
+
+// recoveringEmitter recovers a panic from an emitter, so a single emitter
+// can only lose its own metrics for the tick.
+type recoveringEmitter struct {
+	logger  *zap.Logger
+	name    string
+	emitter emitter.TickEmitter
+}
+
+func newRecoveringEmitter(logger *zap.Logger, name string, e emitter.Emitter) emitter.TickEmitter {
+	return &recoveringEmitter{
+		logger:  logger,
+		name:    name,
+		emitter: emitter.AsTickEmitter(e),
+	}
+}
+
+func (re *recoveringEmitter) EmitTick(ctx context.Context, tick time.Time) {
+	defer func() {
+		if r := recover(); r != nil {
+			re.logger.Error("emitter panicked", zap.String("emitter", re.name), zap.String("panic", fmt.Sprint(r)), zap.ByteString("stack", debug.Stack()))
+		}
+	}()
+	re.emitter.EmitTick(ctx, tick)
+}
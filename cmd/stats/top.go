@@ -0,0 +1,140 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/squizzling/stats/internal/istats"
+	"github.com/squizzling/stats/internal/ticker"
+	"github.com/squizzling/stats/internal/top"
+	"github.com/squizzling/stats/pkg/emitter"
+)
+
+// topEmitter is an emitter with the pool it writes to, so the view can be
+// grouped by emitter.
+type topEmitter struct {
+	name       string
+	emitter    emitter.TickEmitter
+	memoryPool *istats.MemoryPool
+}
+
+func readKeys(keys chan<- byte) {
+	buf := make([]byte, 1)
+	for {
+		n, err := os.Stdin.Read(buf)
+		if err != nil {
+			close(keys)
+			return
+		}
+		if n == 1 {
+			keys <- buf[0]
+		}
+	}
+}
+
+// runTop shows the output of the emitters in a refreshing terminal view,
+// until q is pressed or the process is interrupted.
+func runTop(emitters []*topEmitter, interval time.Duration) error {
+	term, err := top.NewTerminal(int(os.Stdin.Fd()))
+	if err != nil {
+		return fmt.Errorf("stdin is not a terminal: %w", err)
+	}
+	defer func() {
+		term.Restore()
+		fmt.Print("\r\n")
+	}()
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGINT, syscall.SIGTERM)
+	keys := make(chan byte)
+	go readKeys(keys)
+
+	model := top.NewModel(interval)
+	filter := &top.Filter{}
+	editing := false
+	text := ""
+
+	render := func() {
+		prompt := ""
+		if editing {
+			prompt = "filter: " + text
+		}
+		width, height := top.Size(int(os.Stdout.Fd()))
+		model.Render(os.Stdout, filter, prompt, width, height)
+	}
+
+	collect := func(tick time.Time) {
+		ctx, cancel := context.WithDeadline(context.Background(), tick.Add(interval))
+		defer cancel()
+		for _, te := range emitters {
+			te.emitter.EmitTick(ctx, tick)
+			model.Update(te.name, te.memoryPool.Drain(), tick)
+		}
+		model.Expire()
+	}
+
+	collect(time.Now())
+	render()
+
+	tckr := ticker.NewAlignedTicker(interval, 0)
+	defer tckr.Stop()
+	for {
+		select {
+		case tick := <-tckr.C:
+			collect(tick)
+		case <-signals:
+			return nil
+		case key, ok := <-keys:
+			if !ok {
+				return nil
+			}
+			switch {
+			case editing && (key == '\r' || key == '\n'):
+				filter.Text = text
+				editing = false
+			case editing && key == 0x1b:
+				editing = false
+			case editing && (key == 0x7f || key == 0x08):
+				if len(text) > 0 {
+					text = text[:len(text)-1]
+				}
+			case editing:
+				if key >= ' ' && key < 0x7f {
+					text += string(key)
+				}
+			case key == 'q':
+				return nil
+			case key == '/':
+				editing = true
+				text = filter.Text
+			case key == 'e':
+				filter.Emitter = nextEmitter(model.Emitters(), filter.Emitter)
+			case key == 0x1b:
+				filter.Emitter = ""
+				filter.Text = ""
+			}
+		}
+		render()
+	}
+}
+
+// nextEmitter returns the emitter after current, wrapping around through all
+// emitters, which is the empty string.
+func nextEmitter(emitters []string, current string) string {
+	if current == "" {
+		if len(emitters) == 0 {
+			return ""
+		}
+		return emitters[0]
+	}
+	for idx, e := range emitters {
+		if e == current && idx+1 < len(emitters) {
+			return emitters[idx+1]
+		}
+	}
+	return ""
+}
@@ -3,7 +3,6 @@ module github.com/squizzling/stats
 go 1.14
 
 require (
-	github.com/alexcesaro/statsd v2.0.0+incompatible
 	github.com/aws/aws-sdk-go-v2 v1.9.1
 	github.com/aws/aws-sdk-go-v2/config v1.8.2
 	github.com/aws/aws-sdk-go-v2/service/s3 v1.16.0
@@ -14,5 +13,4 @@ require (
 	github.com/tilinna/clock v1.0.2
 	go.uber.org/zap v1.15.0
 	golang.org/x/sys v0.0.0-20190412213103-97732733099d
-	gopkg.in/alexcesaro/statsd.v2 v2.0.0 // indirect
 )
@@ -0,0 +1,25 @@
+package backoff
+
+import (
+	"time"
+)
+
+type BackoffOpts struct {
+	Initial time.Duration `long:"backoff.initial" default:"10s" description:"time to wait after the first failure of an external command, device, or API"`
+	Max     time.Duration `long:"backoff.max"     default:"5m"  description:"maximum time to wait after repeated failures"`
+	Jitter  float64       `long:"backoff.jitter"  default:"0.2" description:"fraction of the wait which is randomised, so failures don't synchronise"`
+}
+
+func (opts *BackoffOpts) Validate() []string {
+	var errs []string
+	if opts.Initial <= 0 {
+		errs = append(errs, "backoff.initial must be positive")
+	}
+	if opts.Max < opts.Initial {
+		errs = append(errs, "backoff.max must not be less than backoff.initial")
+	}
+	if opts.Jitter < 0 || opts.Jitter >= 1 {
+		errs = append(errs, "backoff.jitter must be at least 0 and less than 1")
+	}
+	return errs
+}
@@ -0,0 +1,168 @@
+package backoff
+
+import (
+	"math/rand"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/squizzling/stats/pkg/statser"
+)
+
+// State is the state of a Breaker.  The values are reported as the
+// backoff.state gauge, so they are ordered from healthy to unhealthy.
+type State int
+
+const (
+	// StateClosed means calls are made as normal.
+	StateClosed State = iota
+	// StateHalfOpen means the wait after a failure has passed, and the next
+	// call is a probe whose outcome closes or reopens the breaker.
+	StateHalfOpen
+	// StateOpen means calls are skipped until the wait has passed.
+	StateOpen
+)
+
+func (s State) String() string {
+	switch s {
+	case StateClosed:
+		return "closed"
+	case StateHalfOpen:
+		return "half-open"
+	case StateOpen:
+		return "open"
+	default:
+		return "unknown"
+	}
+}
+
+// Breaker guards calls to something external to the process, such as a
+// command, a device, or an API.  After a failure the calls are skipped for
+// a wait which doubles with each consecutive failure, up to a maximum, and
+// is randomised so that failures across emitters or hosts don't synchronise.
+//
+// The expected use is once per round of calls, typically a tick:
+//
+//	if !b.Allow() {
+//		return
+//	}
+//	if b.Check("action", err) {
+//		return
+//	}
+//	b.Success()
+//
+// A Breaker is safe for concurrent use.
+type Breaker struct {
+	logger *zap.Logger
+	name   string
+	opts   *BackoffOpts
+
+	lock      sync.Mutex
+	state     State
+	failures  int
+	openUntil time.Time
+}
+
+// NewBreaker creates a closed Breaker.  The name identifies it in logs and
+// in the component tag of the reported metrics.
+func NewBreaker(logger *zap.Logger, name string, opts *BackoffOpts) *Breaker {
+	return &Breaker{
+		logger: logger.With(zap.String("component", name)),
+		name:   name,
+		opts:   opts,
+	}
+}
+
+// Allow reports whether calls should be made.  Once the wait after a failure
+// has passed, the breaker becomes half-open and calls are allowed again.
+func (b *Breaker) Allow() bool {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	if b.state == StateOpen {
+		if time.Now().Before(b.openUntil) {
+			return false
+		}
+		b.state = StateHalfOpen
+		b.logger.Info("probing after backoff", zap.Int("failures", b.failures))
+	}
+	return true
+}
+
+// Success records a round of calls which completed without failure, and
+// closes the breaker.  It does nothing if a failure was recorded since the
+// last call to Allow, so it is safe to call unconditionally at the end of a
+// round.
+func (b *Breaker) Success() {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	if b.state == StateOpen {
+		return
+	}
+	if b.state == StateHalfOpen {
+		b.logger.Info("recovered", zap.Int("failures", b.failures))
+	}
+	b.state = StateClosed
+	b.failures = 0
+}
+
+// Failure records a failed call, and opens the breaker.
+func (b *Breaker) Failure(action string, err error) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	if b.state == StateOpen {
+		// Further failures in the same round don't extend the wait.
+		b.logger.Warn("failed", zap.String("action", action), zap.Error(err))
+		return
+	}
+	b.failures++
+	delay := b.delay()
+	b.state = StateOpen
+	b.openUntil = time.Now().Add(delay)
+	b.logger.Error(
+		"failed, backing off",
+		zap.String("action", action),
+		zap.Int("failures", b.failures),
+		zap.Duration("delay", delay),
+		zap.Error(err),
+	)
+}
+
+// Check records a failure if err is not nil, and reports whether it did.
+func (b *Breaker) Check(action string, err error) bool {
+	if err == nil {
+		return false
+	}
+	b.Failure(action, err)
+	return true
+}
+
+// delay is the wait after the current number of consecutive failures.
+func (b *Breaker) delay() time.Duration {
+	d := b.opts.Initial
+	for i := 1; i < b.failures && d < b.opts.Max; i++ {
+		d *= 2
+	}
+	if d > b.opts.Max {
+		d = b.opts.Max
+	}
+	if b.opts.Jitter > 0 {
+		d = time.Duration(float64(d) * (1 + b.opts.Jitter*(2*rand.Float64()-1)))
+	}
+	return d
+}
+
+// State returns the current state, and the number of consecutive failures.
+func (b *Breaker) State() (State, int) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	return b.state, b.failures
+}
+
+// Report writes the state of the breaker to statsPool.
+func (b *Breaker) Report(statsPool statser.Pool) {
+	state, failures := b.State()
+	c := statsPool.Host("component", b.name)
+	c.Gauge("backoff.state", int(state))
+	c.Gauge("backoff.failures", failures)
+}
@@ -0,0 +1,37 @@
+package check
+
+import (
+	"time"
+)
+
+type CheckOpts struct {
+	Warning  []string      `long:"check.warning"                 description:"threshold expression which raises a warning, may be repeated"`
+	Critical []string      `long:"check.critical"                description:"threshold expression which raises a critical, may be repeated"`
+	Timeout  time.Duration `long:"check.timeout"  default:"10s" description:"maximum time to spend collecting in check mode"`
+
+	Checks []*Check
+}
+
+// Validate parses the threshold expressions.  They are not flattened on
+// commas like other options, as selectors use commas to separate tags.
+func (opts *CheckOpts) Validate() []string {
+	var errs []string
+	for _, text := range opts.Warning {
+		if c, err := Parse(text, StatusWarning); err != nil {
+			errs = append(errs, "check.warning: "+err.Error())
+		} else {
+			opts.Checks = append(opts.Checks, c)
+		}
+	}
+	for _, text := range opts.Critical {
+		if c, err := Parse(text, StatusCritical); err != nil {
+			errs = append(errs, "check.critical: "+err.Error())
+		} else {
+			opts.Checks = append(opts.Checks, c)
+		}
+	}
+	if opts.Timeout <= 0 {
+		errs = append(errs, "check.timeout must be positive")
+	}
+	return errs
+}
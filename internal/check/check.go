@@ -0,0 +1,258 @@
+package check
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/squizzling/stats/internal/istats"
+)
+
+// Status is a Nagios plugin status, and doubles as the process exit code.
+type Status int
+
+const (
+	StatusOK       = Status(0)
+	StatusWarning  = Status(1)
+	StatusCritical = Status(2)
+	StatusUnknown  = Status(3)
+)
+
+func (s Status) String() string {
+	switch s {
+	case StatusOK:
+		return "OK"
+	case StatusWarning:
+		return "WARNING"
+	case StatusCritical:
+		return "CRITICAL"
+	default:
+		return "UNKNOWN"
+	}
+}
+
+// severity orders statuses so that a critical outranks a warning, which
+// outranks not knowing, which outranks everything being fine.
+func (s Status) severity() int {
+	switch s {
+	case StatusCritical:
+		return 3
+	case StatusWarning:
+		return 2
+	case StatusUnknown:
+		return 1
+	default:
+		return 0
+	}
+}
+
+func worst(a, b Status) Status {
+	if b.severity() > a.severity() {
+		return b
+	}
+	return a
+}
+
+// Check is a single threshold expression, such as
+// "diskfree.used/diskfree.capacity > 0.9", and the status it raises when a
+// series breaches it.  Metric is the normalised left hand side, so that a
+// warning and a critical for the same expression share perfdata.
+type Check struct {
+	Metric    string
+	Op        string
+	Threshold float64
+	Status    Status
+
+	expr node
+}
+
+func Parse(text string, status Status) (*Check, error) {
+	p := &parser{input: text}
+	expr, err := p.parseExpr()
+	if err != nil {
+		return nil, err
+	}
+	op, err := p.parseComparison()
+	if err != nil {
+		return nil, err
+	}
+	threshold, err := p.parseNumber()
+	if err != nil {
+		return nil, err
+	}
+	if p.peek() != 0 {
+		return nil, p.errorf("unexpected trailing input")
+	}
+	return &Check{
+		Metric:    expr.String(),
+		Op:        op,
+		Threshold: threshold,
+		Status:    status,
+		expr:      expr,
+	}, nil
+}
+
+// perfThreshold renders the threshold as a Nagios range which alerts on the
+// same values as the check, where one exists.
+func (c *Check) perfThreshold() string {
+	switch c.Op {
+	case ">", ">=":
+		return formatValue(c.Threshold)
+	case "<", "<=":
+		return formatValue(c.Threshold) + ":"
+	default:
+		return ""
+	}
+}
+
+type perfData struct {
+	label    string
+	value    float64
+	warning  string
+	critical string
+}
+
+type Report struct {
+	Status Status
+
+	series   int
+	breaches map[Status]int
+	details  []string
+	perf     []*perfData
+}
+
+func formatValue(v float64) string {
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
+
+// displayTags renders the tags of a series without the host tag, which is the
+// same for everything collected by a check.
+func displayTags(s *series) string {
+	if _, ok := s.tags["host"]; !ok {
+		return s.key
+	}
+	tags := make(map[string]string, len(s.tags))
+	for k, v := range s.tags {
+		if k != "host" {
+			tags[k] = v
+		}
+	}
+	return tagKey(tags)
+}
+
+// Evaluate applies every check to the samples, and produces a Report which
+// has the worst status of any check.
+func Evaluate(checks []*Check, samples []istats.Sample) *Report {
+	ss := newSeriesSet(samples)
+	r := &Report{
+		Status:   StatusOK,
+		breaches: make(map[Status]int),
+	}
+	perfByLabel := make(map[string]*perfData)
+
+	for _, c := range checks {
+		v := c.expr.eval(ss)
+		found := 0
+		for _, s := range v.series {
+			if math.IsNaN(s.value) || math.IsInf(s.value, 0) {
+				continue
+			}
+			found++
+
+			// A bare selector is labelled by its name, as its tags are
+			// already on the series.
+			label := c.Metric
+			if sel, ok := c.expr.(*selectorNode); ok {
+				label = sel.name
+			}
+			if tags := displayTags(s); tags != "" {
+				label += "{" + tags + "}"
+			}
+
+			pd, ok := perfByLabel[label]
+			if !ok {
+				pd = &perfData{
+					label: label,
+					value: s.value,
+				}
+				perfByLabel[label] = pd
+				r.perf = append(r.perf, pd)
+				r.series++
+			}
+			if c.Status == StatusCritical {
+				pd.critical = c.perfThreshold()
+			} else {
+				pd.warning = c.perfThreshold()
+			}
+
+			if compare(c.Op, s.value, c.Threshold) {
+				r.Status = worst(r.Status, c.Status)
+				r.breaches[c.Status]++
+				r.details = append(r.details, fmt.Sprintf("%s: %s = %s (%s %s)", c.Status, label, formatValue(s.value), c.Op, formatValue(c.Threshold)))
+			}
+		}
+		if found == 0 {
+			r.Status = worst(r.Status, StatusUnknown)
+			r.breaches[StatusUnknown]++
+			r.details = append(r.details, fmt.Sprintf("%s: no data for %s", StatusUnknown, c.Metric))
+		}
+	}
+
+	sort.Slice(r.perf, func(i, j int) bool {
+		return r.perf[i].label < r.perf[j].label
+	})
+	return r
+}
+
+func (r *Report) summary() string {
+	if r.Status == StatusOK {
+		return fmt.Sprintf("%d series within thresholds", r.series)
+	}
+	var parts []string
+	for _, s := range []Status{StatusCritical, StatusWarning, StatusUnknown} {
+		if n := r.breaches[s]; n > 0 {
+			parts = append(parts, fmt.Sprintf("%d %s", n, strings.ToLower(s.String())))
+		}
+	}
+	return fmt.Sprintf("%s of %d series", strings.Join(parts, ", "), r.series)
+}
+
+// perfLabel quotes a label for perfdata, which may not contain an equals sign
+// or a single quote.
+func perfLabel(label string) string {
+	label = strings.Replace(label, "=", ":", -1)
+	label = strings.Replace(label, "'", "_", -1)
+	return "'" + label + "'"
+}
+
+// Write renders the report in the Nagios plugin output format: a status line
+// with perfdata, followed by one line for each breach.
+func (r *Report) Write(w io.Writer) {
+	sb := strings.Builder{}
+	sb.WriteString("STATS ")
+	sb.WriteString(r.Status.String())
+	sb.WriteString(" - ")
+	sb.WriteString(r.summary())
+	if len(r.perf) > 0 {
+		sb.WriteString(" |")
+		for _, pd := range r.perf {
+			sb.WriteByte(' ')
+			sb.WriteString(perfLabel(pd.label))
+			sb.WriteByte('=')
+			sb.WriteString(formatValue(pd.value))
+			sb.WriteByte(';')
+			sb.WriteString(pd.warning)
+			sb.WriteByte(';')
+			sb.WriteString(pd.critical)
+		}
+	}
+	sb.WriteByte('\n')
+	for _, detail := range r.details {
+		sb.WriteString(detail)
+		sb.WriteByte('\n')
+	}
+	_, _ = io.WriteString(w, sb.String())
+}
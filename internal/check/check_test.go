@@ -0,0 +1,175 @@
+package check
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/squizzling/stats/internal/istats"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		text      string
+		metric    string
+		op        string
+		threshold float64
+		err       bool
+	}{
+		{text: "a > 1", metric: "a", op: ">", threshold: 1},
+		{text: "a>=1", metric: "a", op: ">=", threshold: 1},
+		{text: "a < -1", metric: "a", op: "<", threshold: -1},
+		{text: "a <= .5", metric: "a", op: "<=", threshold: 0.5},
+		{text: "a > 1e-3", metric: "a", op: ">", threshold: 0.001},
+		{text: "a > 2E+2", metric: "a", op: ">", threshold: 200},
+		{text: "a.b_c{x=1} > 0", metric: "a.b_c{x=1}", op: ">", threshold: 0},
+		{text: "a{y=2, x=1} > 0", metric: "a{x=1,y=2}", op: ">", threshold: 0},
+
+		// precedence
+		{text: "a + b * c > 0", metric: "a+b*c", op: ">", threshold: 0},
+		{text: "(a + b) * c > 0", metric: "(a+b)*c", op: ">", threshold: 0},
+		{text: "a - (b - c) > 0", metric: "a-(b-c)", op: ">", threshold: 0},
+		{text: "a - b - c > 0", metric: "a-b-c", op: ">", threshold: 0},
+		{text: "a / b / c > 0", metric: "a/b/c", op: ">", threshold: 0},
+		{text: "a / (b * c) > 0", metric: "a/(b*c)", op: ">", threshold: 0},
+
+		// unary minus
+		{text: "-a > 0", metric: "-a", op: ">", threshold: 0},
+		{text: "-1 * a > 0", metric: "-1*a", op: ">", threshold: 0},
+		{text: "a - -b > 0", metric: "a--b", op: ">", threshold: 0},
+		{text: "-(a + b) > 0", metric: "-(a+b)", op: ">", threshold: 0},
+
+		// errors
+		{text: "", err: true},
+		{text: "a", err: true},
+		{text: "a >", err: true},
+		{text: "a > -", err: true},
+		{text: "a > 1e", err: true},
+		{text: "a > 1 b", err: true},
+		{text: "(a > 1", err: true},
+		{text: "a{x} > 1", err: true},
+		{text: "a + > 1", err: true},
+	}
+	for _, test := range tests {
+		c, err := Parse(test.text, StatusWarning)
+		if test.err {
+			if err == nil {
+				t.Errorf("%q: expected an error, got %s %s %v", test.text, c.Metric, c.Op, c.Threshold)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%q: unexpected error: %v", test.text, err)
+			continue
+		}
+		if c.Metric != test.metric || c.Op != test.op || c.Threshold != test.threshold {
+			t.Errorf("%q: got %s %s %v, expected %s %s %v", test.text, c.Metric, c.Op, c.Threshold, test.metric, test.op, test.threshold)
+		}
+	}
+}
+
+func gauge(name string, value float64, tags ...string) istats.Sample {
+	return istats.Sample{
+		Name:  name,
+		Kind:  istats.KindGauge,
+		Tags:  append([]string{"host", "h"}, tags...),
+		Value: value,
+	}
+}
+
+func TestEvaluate(t *testing.T) {
+	samples := []istats.Sample{
+		gauge("used", 90, "mount", "/"),
+		gauge("used", 10, "mount", "/home"),
+		gauge("capacity", 100, "mount", "/"),
+		gauge("capacity", 100, "mount", "/home"),
+		gauge("capacity", 100, "mount", "/var"),
+		gauge("load", 4),
+		gauge("zero", 0),
+	}
+	tests := []struct {
+		name     string
+		warning  []string
+		critical []string
+		status   Status
+		series   int
+	}{
+		{name: "ok", warning: []string{"load > 5"}, status: StatusOK, series: 1},
+		{name: "warning", warning: []string{"load > 3"}, status: StatusWarning, series: 1},
+		{name: "critical", warning: []string{"load > 3"}, critical: []string{"load > 3.5"}, status: StatusCritical, series: 1},
+		{name: "critical outranks unknown", warning: []string{"missing > 1"}, critical: []string{"load > 1"}, status: StatusCritical, series: 1},
+		{name: "unknown", warning: []string{"missing > 1"}, status: StatusUnknown, series: 0},
+		{name: "warning outranks unknown", warning: []string{"missing > 1", "load > 1"}, status: StatusWarning, series: 1},
+		{name: "nan is no data", warning: []string{"zero / zero > 1"}, status: StatusUnknown, series: 0},
+		{name: "unary minus", warning: []string{"-load < -3"}, status: StatusWarning, series: 1},
+
+		// Series are paired by tags, so /var has no used and is skipped.
+		{name: "matched", warning: []string{"used / capacity > 0.8"}, status: StatusWarning, series: 2},
+		{name: "matched ok", warning: []string{"used / capacity > 0.95"}, status: StatusOK, series: 2},
+		{name: "selected by tag", warning: []string{"used{mount=/home} > 50"}, status: StatusOK, series: 1},
+		{name: "no series with tag", warning: []string{"used{mount=/var} > 50"}, status: StatusUnknown, series: 0},
+		{name: "scalar applied to each", warning: []string{"capacity - 50 > 49"}, status: StatusWarning, series: 3},
+	}
+	for _, test := range tests {
+		var checks []*Check
+		for _, text := range test.warning {
+			c, err := Parse(text, StatusWarning)
+			if err != nil {
+				t.Fatalf("%s: %v", test.name, err)
+			}
+			checks = append(checks, c)
+		}
+		for _, text := range test.critical {
+			c, err := Parse(text, StatusCritical)
+			if err != nil {
+				t.Fatalf("%s: %v", test.name, err)
+			}
+			checks = append(checks, c)
+		}
+		r := Evaluate(checks, samples)
+		if r.Status != test.status {
+			t.Errorf("%s: got status %s, expected %s", test.name, r.Status, test.status)
+		}
+		if r.series != test.series {
+			t.Errorf("%s: got %d series, expected %d", test.name, r.series, test.series)
+		}
+	}
+}
+
+func TestStatusExitCodes(t *testing.T) {
+	for status, code := range map[Status]int{
+		StatusOK:       0,
+		StatusWarning:  1,
+		StatusCritical: 2,
+		StatusUnknown:  3,
+	} {
+		if int(status) != code {
+			t.Errorf("%s: got exit code %d, expected %d", status, int(status), code)
+		}
+	}
+}
+
+func TestReportWrite(t *testing.T) {
+	warning, _ := Parse("used / capacity > 0.8", StatusWarning)
+	critical, _ := Parse("used / capacity > 0.85", StatusCritical)
+	r := Evaluate([]*Check{warning, critical}, []istats.Sample{
+		gauge("used", 90, "mount", "/"),
+		gauge("capacity", 100, "mount", "/"),
+	})
+	var buf bytes.Buffer
+	r.Write(&buf)
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	expected := []string{
+		"STATS CRITICAL - 1 critical, 1 warning of 1 series | 'used/capacity{mount:/}'=0.9;0.8;0.85",
+		"WARNING: used/capacity{mount:/} = 0.9 (> 0.8)",
+		"CRITICAL: used/capacity{mount:/} = 0.9 (> 0.85)",
+	}
+	if len(lines) != len(expected) {
+		t.Fatalf("got %q, expected %q", lines, expected)
+	}
+	for i := range lines {
+		if lines[i] != expected[i] {
+			t.Errorf("line %d: got %q, expected %q", i, lines[i], expected[i])
+		}
+	}
+}
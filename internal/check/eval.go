@@ -0,0 +1,157 @@
+package check
+
+import (
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/squizzling/stats/internal/istats"
+)
+
+type series struct {
+	tags  map[string]string
+	key   string
+	value float64
+}
+
+// vector is the result of evaluating a node.  A scalar is a vector with a
+// single untagged series and isScalar set, so it can be applied to every
+// series on the other side of a binary operator.
+type vector struct {
+	isScalar bool
+	series   []*series
+}
+
+type seriesSet struct {
+	byName map[string][]*series
+}
+
+func tagKey(tags map[string]string) string {
+	return formatTags(tags, ':')
+}
+
+func formatTags(tags map[string]string, separator byte) string {
+	keys := make([]string, 0, len(tags))
+	for k := range tags {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	sb := strings.Builder{}
+	for i, k := range keys {
+		if i != 0 {
+			sb.WriteByte(',')
+		}
+		sb.WriteString(k)
+		sb.WriteByte(separator)
+		sb.WriteString(tags[k])
+	}
+	return sb.String()
+}
+
+func newSeriesSet(samples []istats.Sample) *seriesSet {
+	ss := &seriesSet{
+		byName: make(map[string][]*series),
+	}
+	index := make(map[string]*series)
+	for _, sample := range samples {
+		tags := make(map[string]string, len(sample.Tags)/2)
+		for i := 0; i+1 < len(sample.Tags); i += 2 {
+			tags[sample.Tags[i]] = sample.Tags[i+1]
+		}
+		key := tagKey(tags)
+		if s, ok := index[sample.Name+"{"+key]; ok {
+			s.value = sample.Value // last one wins
+			continue
+		}
+		s := &series{
+			tags:  tags,
+			key:   key,
+			value: sample.Value,
+		}
+		index[sample.Name+"{"+key] = s
+		ss.byName[sample.Name] = append(ss.byName[sample.Name], s)
+	}
+	return ss
+}
+
+func (n *numberNode) eval(ss *seriesSet) vector {
+	return vector{
+		isScalar: true,
+		series:   []*series{{value: n.value}},
+	}
+}
+
+func (n *selectorNode) eval(ss *seriesSet) vector {
+	var v vector
+nextSeries:
+	for _, s := range ss.byName[n.name] {
+		for k, want := range n.tags {
+			if have, ok := s.tags[k]; !ok || have != want {
+				continue nextSeries
+			}
+		}
+		v.series = append(v.series, s)
+	}
+	return v
+}
+
+func (n *negateNode) eval(ss *seriesSet) vector {
+	operand := n.operand.eval(ss)
+	v := vector{isScalar: operand.isScalar}
+	for _, s := range operand.series {
+		v.series = append(v.series, &series{tags: s.tags, key: s.key, value: -s.value})
+	}
+	return v
+}
+
+func apply(op byte, left, right float64) float64 {
+	switch op {
+	case '+':
+		return left + right
+	case '-':
+		return left - right
+	case '*':
+		return left * right
+	case '/':
+		return left / right
+	default:
+		return math.NaN()
+	}
+}
+
+func (n *binaryNode) eval(ss *seriesSet) vector {
+	left := n.left.eval(ss)
+	right := n.right.eval(ss)
+
+	switch {
+	case left.isScalar && right.isScalar:
+		return vector{
+			isScalar: true,
+			series:   []*series{{value: apply(n.op, left.series[0].value, right.series[0].value)}},
+		}
+	case left.isScalar:
+		var v vector
+		for _, s := range right.series {
+			v.series = append(v.series, &series{tags: s.tags, key: s.key, value: apply(n.op, left.series[0].value, s.value)})
+		}
+		return v
+	case right.isScalar:
+		var v vector
+		for _, s := range left.series {
+			v.series = append(v.series, &series{tags: s.tags, key: s.key, value: apply(n.op, s.value, right.series[0].value)})
+		}
+		return v
+	default:
+		byKey := make(map[string]*series, len(right.series))
+		for _, s := range right.series {
+			byKey[s.key] = s
+		}
+		var v vector
+		for _, s := range left.series {
+			if r, ok := byKey[s.key]; ok {
+				v.series = append(v.series, &series{tags: s.tags, key: s.key, value: apply(n.op, s.value, r.value)})
+			}
+		}
+		return v
+	}
+}
@@ -0,0 +1,319 @@
+package check
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// An expression is parsed from:
+//
+//   check    := expr comparison number
+//   expr     := term (('+' | '-') term)*
+//   term     := factor (('*' | '/') factor)*
+//   factor   := number | selector | '-' factor | '(' expr ')'
+//   selector := name ['{' tag '=' value (',' tag '=' value)* '}']
+//   number   := ['-'] digits ['.' digits] [('e' | 'E') ['+' | '-'] digits]
+//
+// A selector matches every series with that metric name which has at least
+// the given tags.  Binary operators between two selectors pair up series
+// with identical tags, and operators between a selector and a number apply
+// the number to every series.
+
+type node interface {
+	eval(s *seriesSet) vector
+	String() string
+}
+
+type numberNode struct {
+	value float64
+}
+
+type selectorNode struct {
+	name string
+	tags map[string]string
+}
+
+type binaryNode struct {
+	op    byte
+	left  node
+	right node
+}
+
+type negateNode struct {
+	operand node
+}
+
+func (n *numberNode) String() string {
+	return formatValue(n.value)
+}
+
+func (n *selectorNode) String() string {
+	if len(n.tags) == 0 {
+		return n.name
+	}
+	return n.name + "{" + formatTags(n.tags, '=') + "}"
+}
+
+func precedence(n node) int {
+	if b, ok := n.(*binaryNode); ok && (b.op == '+' || b.op == '-') {
+		return 1
+	} else if ok {
+		return 2
+	}
+	return 3
+}
+
+func (n *negateNode) String() string {
+	if precedence(n.operand) < 3 {
+		return "-(" + n.operand.String() + ")"
+	}
+	return "-" + n.operand.String()
+}
+
+func (n *binaryNode) String() string {
+	left := n.left.String()
+	if precedence(n.left) < precedence(n) {
+		left = "(" + left + ")"
+	}
+	right := n.right.String()
+	if precedence(n.right) <= precedence(n) && precedence(n.right) != 3 {
+		right = "(" + right + ")"
+	}
+	return left + string(n.op) + right
+}
+
+type parser struct {
+	input string
+	pos   int
+}
+
+func (p *parser) errorf(format string, args ...interface{}) error {
+	return fmt.Errorf("%s at offset %d in %q", fmt.Sprintf(format, args...), p.pos, p.input)
+}
+
+func (p *parser) skipWhitespace() {
+	for p.pos < len(p.input) && (p.input[p.pos] == ' ' || p.input[p.pos] == '\t') {
+		p.pos++
+	}
+}
+
+func (p *parser) peek() byte {
+	p.skipWhitespace()
+	if p.pos >= len(p.input) {
+		return 0
+	}
+	return p.input[p.pos]
+}
+
+func isNameStart(ch byte) bool {
+	return ch == '_' || (ch >= 'a' && ch <= 'z') || (ch >= 'A' && ch <= 'Z')
+}
+
+func isNameChar(ch byte) bool {
+	return isNameStart(ch) || ch == '.' || (ch >= '0' && ch <= '9')
+}
+
+func isNumberStart(ch byte) bool {
+	return ch == '.' || (ch >= '0' && ch <= '9')
+}
+
+func isNumberChar(ch byte) bool {
+	return ch == '.' || (ch >= '0' && ch <= '9')
+}
+
+func (p *parser) parseExpr() (node, error) {
+	left, err := p.parseTerm()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		op := p.peek()
+		if op != '+' && op != '-' {
+			return left, nil
+		}
+		p.pos++
+		right, err := p.parseTerm()
+		if err != nil {
+			return nil, err
+		}
+		left = &binaryNode{op: op, left: left, right: right}
+	}
+}
+
+func (p *parser) parseTerm() (node, error) {
+	left, err := p.parseFactor()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		op := p.peek()
+		if op != '*' && op != '/' {
+			return left, nil
+		}
+		p.pos++
+		right, err := p.parseFactor()
+		if err != nil {
+			return nil, err
+		}
+		left = &binaryNode{op: op, left: left, right: right}
+	}
+}
+
+func (p *parser) parseFactor() (node, error) {
+	ch := p.peek()
+	switch {
+	case ch == '(':
+		p.pos++
+		n, err := p.parseExpr()
+		if err != nil {
+			return nil, err
+		}
+		if p.peek() != ')' {
+			return nil, p.errorf("expected ')'")
+		}
+		p.pos++
+		return n, nil
+	case ch == '-':
+		p.pos++
+		operand, err := p.parseFactor()
+		if err != nil {
+			return nil, err
+		}
+		if n, ok := operand.(*numberNode); ok {
+			return &numberNode{value: -n.value}, nil
+		}
+		return &negateNode{operand: operand}, nil
+	case isNumberStart(ch):
+		value, err := p.parseNumber()
+		if err != nil {
+			return nil, err
+		}
+		return &numberNode{value: value}, nil
+	case isNameStart(ch):
+		return p.parseSelector()
+	case ch == 0:
+		return nil, p.errorf("unexpected end of expression")
+	default:
+		return nil, p.errorf("unexpected %q", ch)
+	}
+}
+
+func (p *parser) parseNumber() (float64, error) {
+	p.skipWhitespace()
+	start := p.pos
+	if p.pos < len(p.input) && p.input[p.pos] == '-' {
+		p.pos++
+	}
+	for p.pos < len(p.input) && isNumberChar(p.input[p.pos]) {
+		p.pos++
+	}
+	if p.pos < len(p.input) && (p.input[p.pos] == 'e' || p.input[p.pos] == 'E') {
+		p.pos++
+		if p.pos < len(p.input) && (p.input[p.pos] == '+' || p.input[p.pos] == '-') {
+			p.pos++
+		}
+		for p.pos < len(p.input) && isNumberChar(p.input[p.pos]) {
+			p.pos++
+		}
+	}
+	value, err := strconv.ParseFloat(p.input[start:p.pos], 64)
+	if err != nil {
+		p.pos = start
+		return 0, p.errorf("invalid number")
+	}
+	return value, nil
+}
+
+func (p *parser) parseName() string {
+	p.skipWhitespace()
+	start := p.pos
+	for p.pos < len(p.input) && isNameChar(p.input[p.pos]) {
+		p.pos++
+	}
+	return p.input[start:p.pos]
+}
+
+func (p *parser) parseSelector() (node, error) {
+	sel := &selectorNode{
+		name: p.parseName(),
+		tags: make(map[string]string),
+	}
+	if p.peek() != '{' {
+		return sel, nil
+	}
+	p.pos++
+	for p.peek() != '}' {
+		key := p.parseName()
+		if key == "" {
+			return nil, p.errorf("expected tag name")
+		}
+		if p.peek() != '=' {
+			return nil, p.errorf("expected '='")
+		}
+		p.pos++
+		value, err := p.parseTagValue()
+		if err != nil {
+			return nil, err
+		}
+		sel.tags[key] = value
+		if p.peek() == ',' {
+			p.pos++
+		}
+	}
+	p.pos++
+	return sel, nil
+}
+
+func (p *parser) parseTagValue() (string, error) {
+	p.skipWhitespace()
+	if p.pos < len(p.input) && p.input[p.pos] == '"' {
+		end := strings.IndexByte(p.input[p.pos+1:], '"')
+		if end == -1 {
+			return "", p.errorf("unterminated quoted value")
+		}
+		value := p.input[p.pos+1 : p.pos+1+end]
+		p.pos += end + 2
+		return value, nil
+	}
+	start := p.pos
+	for p.pos < len(p.input) && p.input[p.pos] != ',' && p.input[p.pos] != '}' {
+		p.pos++
+	}
+	if p.pos >= len(p.input) {
+		return "", p.errorf("expected '}'")
+	}
+	return strings.TrimSpace(p.input[start:p.pos]), nil
+}
+
+var comparisons = []string{">=", "<=", "==", "!=", ">", "<"}
+
+func (p *parser) parseComparison() (string, error) {
+	p.skipWhitespace()
+	for _, c := range comparisons {
+		if strings.HasPrefix(p.input[p.pos:], c) {
+			p.pos += len(c)
+			return c, nil
+		}
+	}
+	return "", p.errorf("expected comparison")
+}
+
+func compare(op string, value, threshold float64) bool {
+	switch op {
+	case ">":
+		return value > threshold
+	case ">=":
+		return value >= threshold
+	case "<":
+		return value < threshold
+	case "<=":
+		return value <= threshold
+	case "==":
+		return value == threshold
+	case "!=":
+		return value != threshold
+	default:
+		return false
+	}
+}
@@ -0,0 +1,153 @@
+// Package docker discovers running containers through the Docker API, so
+// emitters can read their metrics from /proc/<pid>.
+package docker
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+
+	"go.uber.org/zap"
+)
+
+type container struct {
+	Id string
+}
+
+type containerDetail struct {
+	Id    string
+	Name  string
+	State *containerState
+}
+
+type containerState struct {
+	Status  string
+	Running bool
+	Pid     int
+}
+
+var httpClient = &http.Client{
+	Transport: &http.Transport{
+		DialContext: func(ctx context.Context, network, addr string) (net.Conn, error) {
+			return (&net.Dialer{}).DialContext(ctx, "unix", "/var/run/docker.sock")
+		},
+	},
+}
+
+func dockerEnabled() (bool, error) {
+	_, err := os.Stat("/var/run/docker.sock")
+	if err != nil {
+		if os.IsNotExist(err) {
+			return false, nil
+		}
+		// probably a permissions thing
+		return false, err
+	}
+	return true, nil
+}
+
+func queryDocker(ctx context.Context, url string, output interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_, _ = ioutil.ReadAll(resp.Body)
+		_ = resp.Body.Close()
+	}()
+
+	dec := json.NewDecoder(resp.Body)
+	err = dec.Decode(output)
+	if err != nil {
+		return err
+	}
+	return err
+}
+
+func getDockerContainerDetail(ctx context.Context, id string) (*containerDetail, error) {
+	var detail containerDetail
+	if err := queryDocker(ctx, fmt.Sprintf("http://x/containers/%s/json", id), &detail); err != nil {
+		return nil, err
+	}
+	if detail.State == nil {
+		return nil, fmt.Errorf("container %s has no state", id)
+	}
+	detail.Name = strings.TrimLeft(detail.Name, "/")
+	return &detail, nil
+}
+
+func getDockerContainerIDs(ctx context.Context) ([]string, error) {
+	if enabled, err := dockerEnabled(); !enabled {
+		return nil, err
+	}
+	var containers []*container
+	if err := queryDocker(ctx, "http://x/containers/json", &containers); err != nil {
+		return nil, err
+	}
+
+	var ids []string
+	for _, container := range containers {
+		ids = append(ids, container.Id)
+	}
+	return ids, nil
+}
+
+// Container is a running container.  Pid is its init process, so
+// /proc/<pid>/net is its network namespace.
+type Container struct {
+	ID   string
+	Name string
+	Pid  int
+}
+
+// RunningContainers returns the running containers, or none if Docker isn't
+// installed.  A container which can't be inspected, typically because it was
+// removed after being listed, is skipped.
+func RunningContainers(ctx context.Context, logger *zap.Logger) ([]*Container, error) {
+	ids, err := getDockerContainerIDs(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var containers []*Container
+	for _, id := range ids {
+		d, err := getDockerContainerDetail(ctx, id)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil, err
+			}
+			logger.Warn("failed to inspect container, skipping", zap.String("container", id), zap.Error(err))
+			continue
+		}
+		if d.Id != id {
+			logger.Warn("unexpected id", zap.String("original", id), zap.String("found", d.Id))
+			continue
+		}
+		if d.State.Status != "running" {
+			logger.Info("not running, skipping", zap.String("container", id), zap.String("state", d.State.Status))
+			continue
+		}
+		if !d.State.Running {
+			logger.Info("not running, skipping", zap.String("container", id))
+			continue
+		}
+		if d.State.Pid == 0 {
+			logger.Info("pid is 0, skipping", zap.String("container", id))
+			continue
+		}
+		containers = append(containers, &Container{
+			ID:   id,
+			Name: d.Name,
+			Pid:  d.State.Pid,
+		})
+	}
+	return containers, nil
+}
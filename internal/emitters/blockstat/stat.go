@@ -1,73 +1,79 @@
 package blockstat
 
 import (
+	"context"
+	"time"
+
 	"github.com/squizzling/glob/pkg/glob"
 	"go.uber.org/zap"
 
 	"github.com/squizzling/stats/internal/iio"
+	"github.com/squizzling/stats/pkg/collector"
 	"github.com/squizzling/stats/pkg/emitter"
 	"github.com/squizzling/stats/pkg/sources"
-	"github.com/squizzling/stats/pkg/statser"
+	"github.com/squizzling/stats/pkg/sysfs"
 )
 
-type BlockStatEmitter struct {
+type BlockStatCollector struct {
 	logger         *zap.Logger
-	statsPool      statser.Pool
 	devicePatterns glob.Matcher
 }
 
-func NewEmitter(logger *zap.Logger, statsPools statser.Pool, opt emitter.OptProvider) emitter.Emitter {
+func NewCollector(logger *zap.Logger, opt emitter.OptProvider) collector.Collector {
 	opts := opt.Get("blockstat").(*BlockStatOpts)
-	return &BlockStatEmitter{
+	return &BlockStatCollector{
 		logger:         logger,
-		statsPool:      statsPools,
 		devicePatterns: glob.NewACL(opts.IncludeDevice, opts.ExcludeDevice, len(opts.IncludeDevice) == 0),
 	}
 }
 
-const sysBlockRoot = "/sys/block/"
-
-func (bse *BlockStatEmitter) Emit() {
-	es := iio.ReadEntries(bse.logger, sysBlockRoot)
+func (bsc *BlockStatCollector) Collect(ctx context.Context, tick time.Time) ([]collector.Sample, error) {
+	var samples []collector.Sample
+	es := iio.ReadEntries(bsc.logger, sysfs.BlockPath)
 	for _, e := range es {
-		if !bse.devicePatterns.Match(e.Name()) {
+		if !bsc.devicePatterns.Match(e.Name()) {
 			continue
 		}
 
-		bs := LoadBlockStat(bse.logger, e.Name())
-		if bs != nil {
-			if bs.readIOs == 0 && bs.writeIOs == 0 {
-				continue
-			}
-			c := bse.statsPool.Host("device", bs.name)
-			c.Gauge("blockstat.read.requests", bs.readIOs)
-			c.Gauge("blockstat.read.merges", bs.readMerges)
-			c.Gauge("blockstat.read.sectors", bs.readSectors)
-			c.Gauge("blockstat.read.ticks", bs.readTicks)
+		bs, err := sysfs.ReadBlockStat(e.Name())
+		if err != nil {
+			bsc.logger.Warn("failed to read block stat", zap.String("device", e.Name()), zap.Error(err))
+			continue
+		}
+		if bs.ReadIOs == 0 && bs.WriteIOs == 0 {
+			continue
+		}
+		add := func(name string, unit collector.Unit, value uint64) {
+			samples = append(samples, collector.Cumulative(name, unit, float64(value), "device", bs.Name))
+		}
+		add("blockstat.read.requests", collector.UnitOperations, bs.ReadIOs)
+		add("blockstat.read.merges", collector.UnitOperations, bs.ReadMerges)
+		add("blockstat.read.sectors", collector.UnitSectors, bs.ReadSectors)
+		add("blockstat.read.ticks", collector.UnitMilliseconds, bs.ReadTicks)
 
-			c.Gauge("blockstat.write.requests", bs.writeIOs)
-			c.Gauge("blockstat.write.merges", bs.writeMerges)
-			c.Gauge("blockstat.write.sectors", bs.writeSectors)
-			c.Gauge("blockstat.write.ticks", bs.writeTicks)
+		add("blockstat.write.requests", collector.UnitOperations, bs.WriteIOs)
+		add("blockstat.write.merges", collector.UnitOperations, bs.WriteMerges)
+		add("blockstat.write.sectors", collector.UnitSectors, bs.WriteSectors)
+		add("blockstat.write.ticks", collector.UnitMilliseconds, bs.WriteTicks)
 
-			c.Gauge("blockstat.inflight", bs.inFlight)
-			c.Gauge("blockstat.ioticks", bs.ioTicks)
-			c.Gauge("blockstat.timeinqueue", bs.timeInQueue)
+		samples = append(samples, collector.Gauge("blockstat.inflight", collector.UnitOperations, float64(bs.InFlight), "device", bs.Name))
+		add("blockstat.ioticks", collector.UnitMilliseconds, bs.IoTicks)
+		add("blockstat.timeinqueue", collector.UnitMilliseconds, bs.TimeInQueue)
 
-			if bs.version >= v4_19 {
-				c.Gauge("blockstat.discard.requests", bs.discardIOs)
-				c.Gauge("blockstat.discard.merges", bs.discardMerges)
-				c.Gauge("blockstat.discard.sectors", bs.discardSectors)
-				c.Gauge("blockstat.discard.ticks", bs.discardTicks)
-			}
-			if bs.version >= v5_5 {
-				c.Gauge("blockstat.flush.requests", bs.flushIOs)
-				c.Gauge("blockstat.flush.ticks", bs.flushTicks)
-			}
+		if bs.Version >= sysfs.BlockStat4_19 {
+			add("blockstat.discard.requests", collector.UnitOperations, bs.DiscardIOs)
+			add("blockstat.discard.merges", collector.UnitOperations, bs.DiscardMerges)
+			add("blockstat.discard.sectors", collector.UnitSectors, bs.DiscardSectors)
+			add("blockstat.discard.ticks", collector.UnitMilliseconds, bs.DiscardTicks)
+		}
+		if bs.Version >= sysfs.BlockStat5_5 {
+			add("blockstat.flush.requests", collector.UnitOperations, bs.FlushIOs)
+			add("blockstat.flush.ticks", collector.UnitMilliseconds, bs.FlushTicks)
 		}
 	}
+	return samples, nil
 }
 
 func init() {
-	sources.Sources["blockstat"] = NewEmitter
+	sources.Sources["blockstat"] = collector.Factory(NewCollector)
 }
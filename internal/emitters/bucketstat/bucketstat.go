@@ -2,7 +2,6 @@ package bucketstat
 
 import (
 	"context"
-	"fmt"
 	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
@@ -10,7 +9,9 @@ import (
 	"github.com/aws/aws-sdk-go-v2/service/s3"
 	"go.uber.org/zap"
 
+	"github.com/squizzling/stats/internal/backoff"
 	"github.com/squizzling/stats/internal/emitters/bucketstat/paginator"
+	"github.com/squizzling/stats/pkg/collector"
 	"github.com/squizzling/stats/pkg/emitter"
 	"github.com/squizzling/stats/pkg/sources"
 	"github.com/squizzling/stats/pkg/statser"
@@ -31,6 +32,7 @@ type BucketStatEmitter struct {
 	next      int
 
 	s3client *s3.Client
+	breaker  *backoff.Breaker
 }
 
 func NewEmitter(logger *zap.Logger, statsPool statser.Pool, opt emitter.OptProvider) emitter.Emitter {
@@ -42,7 +44,8 @@ func NewEmitter(logger *zap.Logger, statsPool statser.Pool, opt emitter.OptProvi
 	}
 	cfg, err := config.LoadDefaultConfig(context.Background(), awsOpts...)
 	if err != nil {
-		panic(err)
+		logger.Error("failed to load aws config", zap.Error(err))
+		return nil
 	}
 
 	s3client := s3.NewFromConfig(cfg)
@@ -53,23 +56,41 @@ func NewEmitter(logger *zap.Logger, statsPool statser.Pool, opt emitter.OptProvi
 		logger:    logger,
 		statsPool: statsPool,
 		s3client:  s3client,
+		breaker:   backoff.NewBreaker(logger, "bucketstat", opt.Get("backoff").(*backoff.BackoffOpts)),
 	}
 }
 
 func (bse *BucketStatEmitter) Emit() {
+	bse.EmitTick(context.Background(), time.Now())
+}
+
+func (bse *BucketStatEmitter) EmitTick(ctx context.Context, tick time.Time) {
 	bse.next++
 	if bse.next < bse.frequency {
 		return
 	}
 	bse.next = 0
+	defer bse.breaker.Report(bse.statsPool)
+	if !bse.breaker.Allow() {
+		return
+	}
+	// The listing only runs every frequency ticks, so it has until the next
+	// run rather than until the next tick.  Running out of that time is a
+	// failure, as the listing would never finish otherwise.
+	if deadline, ok := ctx.Deadline(); ok {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithDeadline(context.Background(), tick.Add(time.Duration(bse.frequency)*deadline.Sub(tick)))
+		defer cancel()
+	}
 	calls := 0
 	for _, p := range bse.prefix {
-		calls += bse.EmitPrefix(p.bucket, p.prefix)
+		calls += bse.EmitPrefix(ctx, p.bucket, p.prefix)
 	}
 	bse.statsPool.Global().Count("bucketstat.calls", calls)
+	bse.breaker.Success()
 }
 
-func (bse *BucketStatEmitter) EmitPrefix(bucket, prefix string) int {
+func (bse *BucketStatEmitter) EmitPrefix(ctx context.Context, bucket, prefix string) int {
 	pager := paginator.NewListObjectVersionsPaginator(bse.s3client, &s3.ListObjectVersionsInput{
 		Bucket: aws.String(bucket),
 		Prefix: aws.String(prefix),
@@ -81,9 +102,8 @@ func (bse *BucketStatEmitter) EmitPrefix(bucket, prefix string) int {
 	var latest time.Time
 
 	for pager.HasMorePages() {
-		page, err := pager.NextPage(context.Background())
-		if err != nil {
-			bse.logger.Error("NextPage", zap.Error(err))
+		page, err := pager.NextPage(ctx)
+		if bse.breaker.Check("list-object-versions", err) {
 			return pager.Calls()
 		}
 		for _, version := range page.Versions {
@@ -117,12 +137,21 @@ func (bse *BucketStatEmitter) EmitPrefix(bucket, prefix string) int {
 		}
 	}
 
-	bse.statsPool.Global("bucket", bucket, "prefix", "/"+prefix, "state", "active").Gauge("bucketstat.bytes", activeBytes)
+	collector.Describe(bse.statsPool.Global("bucket", bucket, "prefix", "/"+prefix, "state", "active"), collector.KindGauge, collector.UnitBytes).Gauge("bucketstat.bytes", activeBytes)
 	bse.statsPool.Global("bucket", bucket, "prefix", "/"+prefix, "state", "active").Gauge("bucketstat.objects", activeObjectCount)
-	bse.statsPool.Global("bucket", bucket, "prefix", "/"+prefix, "state", "deleted").Gauge("bucketstat.bytes", deletedBytes)
+	collector.Describe(bse.statsPool.Global("bucket", bucket, "prefix", "/"+prefix, "state", "deleted"), collector.KindGauge, collector.UnitBytes).Gauge("bucketstat.bytes", deletedBytes)
 	bse.statsPool.Global("bucket", bucket, "prefix", "/"+prefix, "state", "deleted").Gauge("bucketstat.objects", deadObjectCount)
 	bse.statsPool.Global("bucket", bucket, "prefix", "/"+prefix).Gauge("bucketstat.latest", latest.UnixNano()/1000000)
-	fmt.Printf("%d %d %d %d %d\n", activeBytes, activeObjectCount, deletedBytes, deadObjectCount, latest.UnixNano()/1000000)
+	bse.logger.Debug(
+		"listed prefix",
+		zap.String("bucket", bucket),
+		zap.String("prefix", prefix),
+		zap.Int64("active-bytes", activeBytes),
+		zap.Int("active-objects", activeObjectCount),
+		zap.Int64("deleted-bytes", deletedBytes),
+		zap.Int("deleted-objects", deadObjectCount),
+		zap.Time("latest", latest),
+	)
 
 	return pager.Calls()
 }
@@ -9,6 +9,7 @@ import (
 	"github.com/squizzling/glob/pkg/glob"
 
 	"github.com/squizzling/stats/internal/iio"
+	"github.com/squizzling/stats/pkg/collector"
 	"github.com/squizzling/stats/pkg/emitter"
 	"github.com/squizzling/stats/pkg/sources"
 	"github.com/squizzling/stats/pkg/statser"
@@ -32,8 +33,6 @@ func NewEmitter(logger *zap.Logger, statsPools statser.Pool, opt emitter.OptProv
 	}
 }
 
-const sysBlockRoot = "/sys/block/"
-
 func (dfe *DiskFreeEmitter) Emit() {
 	data := iio.ReadEntireFile(dfe.logger, "/proc/self/mountinfo")
 	lines := iio.SplitLines(data)
@@ -87,7 +86,7 @@ func (dfe *DiskFreeEmitter) Emit() {
 		fsType = strings.Replace(fsType, ",", "_", -1)
 		mountPoint = strings.Replace(mountPoint, ",", "_", -1)
 
-		c := dfe.statsPool.Host("fstype", fsType, "mount", mountPoint)
+		c := collector.Describe(dfe.statsPool.Host("fstype", fsType, "mount", mountPoint), collector.KindGauge, collector.UnitBytes)
 		c.Gauge("diskfree.available", availableBytes)
 		c.Gauge("diskfree.capacity", capacityBytes)
 		c.Gauge("diskfree.used", usedBytes)
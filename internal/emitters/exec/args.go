@@ -0,0 +1,137 @@
+package exec
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/squizzling/stats/internal/textformat"
+)
+
+// ExecOpts configures scripts by name, with every option taking the form
+// name:value.  The values are not flattened on commas, as they are commands
+// and tags.
+type ExecOpts struct {
+	Command  []string `long:"exec.command"  description:"name:command to run, split on spaces and run without a shell, may be repeated"`
+	Format   []string `long:"exec.format"   description:"name:format of the command output, one of statsd, influx, or prometheus (default statsd)"`
+	Interval []string `long:"exec.interval" description:"name:interval between runs of the command (default 1m)"`
+	Timeout  []string `long:"exec.timeout"  description:"name:time to wait for the command before killing it (default 10s)"`
+	Tag      []string `long:"exec.tag"      description:"name:key=value extra tag for samples from the command, may be repeated"`
+
+	scripts []*scriptConfig
+}
+
+type scriptConfig struct {
+	name     string
+	command  string
+	args     []string
+	format   textformat.Format
+	interval time.Duration
+	timeout  time.Duration
+	tags     []string
+}
+
+func splitNameValue(option, s string) (string, string, error) {
+	colon := strings.IndexByte(s, ':')
+	if colon <= 0 {
+		return "", "", fmt.Errorf("%s must be in the form name:value", option)
+	}
+	return s[:colon], s[colon+1:], nil
+}
+
+func (opts *ExecOpts) Validate() []string {
+	var errs []string
+
+	byName := make(map[string]*scriptConfig)
+	for _, s := range opts.Command {
+		name, command, err := splitNameValue("exec.command", s)
+		if err != nil {
+			errs = append(errs, err.Error())
+			continue
+		}
+		fields := strings.Fields(command)
+		if len(fields) == 0 {
+			errs = append(errs, fmt.Sprintf("exec.command for %s is empty", name))
+			continue
+		}
+		if _, ok := byName[name]; ok {
+			errs = append(errs, fmt.Sprintf("exec.command for %s is repeated", name))
+			continue
+		}
+		byName[name] = &scriptConfig{
+			name:     name,
+			command:  fields[0],
+			args:     fields[1:],
+			format:   textformat.FormatStatsd,
+			interval: 1 * time.Minute,
+			timeout:  10 * time.Second,
+		}
+	}
+
+	lookup := func(option, s string) (*scriptConfig, string) {
+		name, value, err := splitNameValue(option, s)
+		if err != nil {
+			errs = append(errs, err.Error())
+			return nil, ""
+		}
+		sc, ok := byName[name]
+		if !ok {
+			errs = append(errs, fmt.Sprintf("%s for %s has no exec.command", option, name))
+			return nil, ""
+		}
+		return sc, value
+	}
+
+	for _, s := range opts.Format {
+		if sc, value := lookup("exec.format", s); sc != nil {
+			format, err := textformat.ParseFormat(value)
+			if err != nil {
+				errs = append(errs, fmt.Sprintf("exec.format for %s: %v", sc.name, err))
+				continue
+			}
+			sc.format = format
+		}
+	}
+
+	for _, s := range opts.Interval {
+		if sc, value := lookup("exec.interval", s); sc != nil {
+			d, err := time.ParseDuration(value)
+			if err != nil || d <= 0 {
+				errs = append(errs, fmt.Sprintf("exec.interval for %s must be a positive duration", sc.name))
+				continue
+			}
+			sc.interval = d
+		}
+	}
+
+	for _, s := range opts.Timeout {
+		if sc, value := lookup("exec.timeout", s); sc != nil {
+			d, err := time.ParseDuration(value)
+			if err != nil || d <= 0 {
+				errs = append(errs, fmt.Sprintf("exec.timeout for %s must be a positive duration", sc.name))
+				continue
+			}
+			sc.timeout = d
+		}
+	}
+
+	for _, s := range opts.Tag {
+		if sc, value := lookup("exec.tag", s); sc != nil {
+			eq := strings.IndexByte(value, '=')
+			if eq <= 0 {
+				errs = append(errs, fmt.Sprintf("exec.tag for %s must be in the form name:key=value", sc.name))
+				continue
+			}
+			sc.tags = append(sc.tags, value[:eq], value[eq+1:])
+		}
+	}
+
+	for _, sc := range byName {
+		opts.scripts = append(opts.scripts, sc)
+	}
+	sort.Slice(opts.scripts, func(i, j int) bool {
+		return opts.scripts[i].name < opts.scripts[j].name
+	})
+	return errs
+}
@@ -0,0 +1,189 @@
+package exec
+
+import (
+	"errors"
+	"fmt"
+	"os/exec"
+	"runtime/debug"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/squizzling/stats/internal/backoff"
+	"github.com/squizzling/stats/internal/iio"
+	"github.com/squizzling/stats/internal/textformat"
+	"github.com/squizzling/stats/internal/ticker"
+	"github.com/squizzling/stats/pkg/collector"
+	"github.com/squizzling/stats/pkg/emitter"
+	"github.com/squizzling/stats/pkg/sources"
+	"github.com/squizzling/stats/pkg/statser"
+)
+
+// ExecEmitter runs external commands and forwards the samples they print.
+// Each command runs in its own goroutine on its own interval, and Emit
+// forwards the results of any runs which completed since the last tick, so
+// the pool is only ever used from the emitting goroutine.  A command which
+// fails is backed off independently of the others.
+type ExecEmitter struct {
+	logger    *zap.Logger
+	statsPool statser.Pool
+	scripts   []*script
+}
+
+type script struct {
+	config  *scriptConfig
+	logger  *zap.Logger
+	breaker *backoff.Breaker
+
+	lock   sync.Mutex
+	result *result
+}
+
+type result struct {
+	samples     []textformat.Sample
+	parseErrors int
+	duration    time.Duration
+	exitCode    int
+	failure     string
+}
+
+func NewEmitter(logger *zap.Logger, statsPool statser.Pool, opt emitter.OptProvider) emitter.Emitter {
+	opts := opt.Get("exec").(*ExecOpts)
+	backoffOpts := opt.Get("backoff").(*backoff.BackoffOpts)
+
+	ee := &ExecEmitter{
+		logger:    logger,
+		statsPool: statsPool,
+	}
+	for _, sc := range opts.scripts {
+		s := &script{
+			config:  sc,
+			logger:  logger.With(zap.String("script", sc.name)),
+			breaker: backoff.NewBreaker(logger, "exec."+sc.name, backoffOpts),
+		}
+		ee.scripts = append(ee.scripts, s)
+		go s.loop()
+	}
+	return ee
+}
+
+func (s *script) loop() {
+	tckr := ticker.NewAlignedTicker(s.config.interval, 0)
+	for range tckr.C {
+		s.tick()
+	}
+}
+
+// tick runs the script once.  The loop isn't covered by the recovery around
+// EmitTick, so a panic is recovered here, and only loses this run.
+func (s *script) tick() {
+	defer func() {
+		if r := recover(); r != nil {
+			s.logger.Error("script run panicked", zap.String("panic", fmt.Sprint(r)), zap.ByteString("stack", debug.Stack()))
+		}
+	}()
+	if !s.breaker.Allow() {
+		return
+	}
+	r := s.run()
+	s.lock.Lock()
+	if s.result != nil {
+		s.logger.Warn("previous result was not emitted, discarding")
+	}
+	s.result = r
+	s.lock.Unlock()
+}
+
+func (s *script) run() *result {
+	start := time.Now()
+	output, err := iio.ExecuteTimeout(s.config.timeout, s.config.command, s.config.args...)
+	r := &result{
+		duration: time.Since(start),
+	}
+
+	if err != nil {
+		var exitErr *exec.ExitError
+		switch {
+		case err == iio.ErrTimeout:
+			r.exitCode = -1
+			r.failure = "timeout"
+		case errors.As(err, &exitErr):
+			r.exitCode = exitErr.ExitCode()
+			r.failure = "exit"
+		default:
+			r.exitCode = -1
+			r.failure = "start"
+		}
+		s.breaker.Failure(r.failure, err)
+		return r
+	}
+	s.breaker.Success()
+
+	samples, errs := textformat.Parse(s.config.format, output)
+	for _, err := range errs {
+		s.logger.Warn("failed to parse output", zap.Error(err))
+	}
+	r.samples = samples
+	r.parseErrors = len(errs)
+	return r
+}
+
+func (s *script) takeResult() *result {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	r := s.result
+	s.result = nil
+	return r
+}
+
+func (ee *ExecEmitter) Emit() {
+	for _, s := range ee.scripts {
+		if r := s.takeResult(); r != nil {
+			ee.emitResult(s.config, r)
+		}
+		s.breaker.Report(ee.statsPool)
+	}
+}
+
+func (ee *ExecEmitter) emitResult(sc *scriptConfig, r *result) {
+	c := ee.statsPool.Host("script", sc.name)
+	c.Count("exec.runs", 1)
+	c.Gauge("exec.duration", r.duration.Seconds())
+	c.Gauge("exec.exit_code", r.exitCode)
+	c.Gauge("exec.samples", len(r.samples))
+	c.Gauge("exec.parse_errors", r.parseErrors)
+	if r.failure != "" {
+		ee.statsPool.Host("script", sc.name, "failure", r.failure).Count("exec.failures", 1)
+	}
+
+	for _, sample := range r.samples {
+		tags := append(append([]string{}, sample.Tags...), sc.tags...)
+		switch {
+		case sample.Kind == textformat.KindCounter && sc.format == textformat.FormatStatsd:
+			// Only statsd counters are deltas.
+			if sample.SampleRate != 1 {
+				ee.statsPool.Host(tags...).Count(sample.Name, sample.Value/sample.SampleRate)
+			} else {
+				ee.statsPool.Host(tags...).Count(sample.Name, sample.Value)
+			}
+		case sample.Kind == textformat.KindCounter:
+			// Counters in the other formats are cumulative totals, and are
+			// written as gauges like textfile does.
+			c := collector.Describe(ee.statsPool.Host(tags...), collector.KindCumulative, collector.UnitNone)
+			c.Gauge(sample.Name, sample.Value)
+		case sample.Kind == textformat.KindSet:
+			ee.logger.Debug("sets are not supported", zap.String("script", sc.name), zap.String("metric", sample.Name))
+		default:
+			if sample.Relative {
+				ee.logger.Debug("relative gauges are not supported", zap.String("script", sc.name), zap.String("metric", sample.Name))
+				continue
+			}
+			ee.statsPool.Host(tags...).Gauge(sample.Name, sample.Value)
+		}
+	}
+}
+
+func init() {
+	sources.Sources["exec"] = NewEmitter
+}
@@ -0,0 +1,16 @@
+package ipmi
+
+import (
+	"time"
+)
+
+type IPMIOpts struct {
+	Timeout time.Duration `long:"ipmi.timeout" default:"10s" description:"time after which ipmi-sensors is killed, and counted as a failure"`
+}
+
+func (opts *IPMIOpts) Validate() []string {
+	if opts.Timeout <= 0 {
+		return []string{"ipmi.timeout must be positive"}
+	}
+	return nil
+}
@@ -2,12 +2,14 @@ package ipmi
 
 import (
 	"bytes"
+	"context"
 	"encoding/csv"
 	"strconv"
 	"time"
 
 	"go.uber.org/zap"
 
+	"github.com/squizzling/stats/internal/backoff"
 	"github.com/squizzling/stats/internal/iio"
 	"github.com/squizzling/stats/pkg/emitter"
 	"github.com/squizzling/stats/pkg/sources"
@@ -18,20 +20,25 @@ type IPMIEmitter struct {
 	logger    *zap.Logger
 	statsPool statser.Pool
 
-	pauseUntil time.Time
+	timeout time.Duration
+	breaker *backoff.Breaker
 }
 
 func NewEmitter(logger *zap.Logger, statsPool statser.Pool, opt emitter.OptProvider) emitter.Emitter {
 	return &IPMIEmitter{
-		logger:     logger,
-		statsPool:  statsPool,
-		pauseUntil: time.Unix(0, 0),
+		logger:    logger,
+		statsPool: statsPool,
+		timeout:   opt.Get("ipmi").(*IPMIOpts).Timeout,
+		breaker:   backoff.NewBreaker(logger, "ipmi", opt.Get("backoff").(*backoff.BackoffOpts)),
 	}
 }
 
+// readIPMI runs ipmi-sensors with its own timeout rather than the tick's
+// deadline, as it's routinely slower than an interval, and a command which
+// is killed for running too long is a failure to back off from.
 func (ie *IPMIEmitter) readIPMI() map[int64]map[string]string {
-	rawData, err := iio.Execute("/usr/sbin/ipmi-sensors", "--comma-separated-output")
-	if ie.check("ipmi-sensors", err) {
+	rawData, err := iio.ExecuteTimeout(ie.timeout, "/usr/sbin/ipmi-sensors", "--comma-separated-output")
+	if ie.breaker.Check("ipmi-sensors", err) {
 		return nil
 	}
 
@@ -63,11 +70,17 @@ func (ie *IPMIEmitter) readIPMI() map[int64]map[string]string {
 }
 
 func (ie *IPMIEmitter) Emit() {
-	if time.Now().Before(ie.pauseUntil) {
+	ie.EmitTick(context.Background(), time.Now())
+}
+
+func (ie *IPMIEmitter) EmitTick(ctx context.Context, tick time.Time) {
+	defer ie.breaker.Report(ie.statsPool)
+	if !ie.breaker.Allow() {
 		return
 	}
 
 	sensors := ie.readIPMI()
+	ie.breaker.Success()
 	for _, sensor := range sensors {
 		switch sensor["Type"] {
 		case "Temperature": // process
@@ -118,15 +131,6 @@ func (ie *IPMIEmitter) emitVoltage(sensor map[string]string) {
 	client.Gauge("ipmi.voltage", sensorValue)
 }
 
-func (ie *IPMIEmitter) check(action string, err error) bool {
-	if err == nil {
-		return false
-	}
-	ie.logger.Error("error", zap.String("action", action), zap.Error(err))
-	ie.pauseUntil = time.Now().Add(5 * time.Minute)
-	return true
-}
-
 func init() {
 	sources.Sources["ipmi"] = NewEmitter
 }
@@ -0,0 +1,9 @@
+package loadavg
+
+type LoadAvgOpts struct {
+	Normalise bool `long:"loadavg.normalise" description:"also emit the load divided by the number of online CPUs"`
+}
+
+func (opts *LoadAvgOpts) Validate() []string {
+	return nil
+}
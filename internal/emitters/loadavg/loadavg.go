@@ -0,0 +1,71 @@
+package loadavg
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/squizzling/stats/pkg/collector"
+	"github.com/squizzling/stats/pkg/emitter"
+	"github.com/squizzling/stats/pkg/procfs"
+	"github.com/squizzling/stats/pkg/sources"
+	"github.com/squizzling/stats/pkg/sysfs"
+)
+
+type LoadAvgCollector struct {
+	logger    *zap.Logger
+	normalise bool
+}
+
+func NewCollector(logger *zap.Logger, opt emitter.OptProvider) collector.Collector {
+	opts := opt.Get("loadavg").(*LoadAvgOpts)
+	return &LoadAvgCollector{
+		logger:    logger,
+		normalise: opts.Normalise,
+	}
+}
+
+func (lac *LoadAvgCollector) Collect(ctx context.Context, tick time.Time) ([]collector.Sample, error) {
+	la, err := procfs.ReadLoadAvg(procfs.LoadAvgPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read loadavg: %w", err)
+	}
+	samples := []collector.Sample{
+		collector.Gauge("loadavg.load1", collector.UnitNone, la.Load1),
+		collector.Gauge("loadavg.load5", collector.UnitNone, la.Load5),
+		collector.Gauge("loadavg.load15", collector.UnitNone, la.Load15),
+		collector.Gauge("loadavg.runnable", collector.UnitNone, float64(la.Runnable)),
+		collector.Gauge("loadavg.entities", collector.UnitNone, float64(la.Entities)),
+		collector.Gauge("loadavg.last_pid", collector.UnitNone, float64(la.LastPID)),
+	}
+
+	if lac.normalise {
+		// The CPU count can change with hotplug, so it's read every time.
+		if cpus, err := sysfs.ReadOnlineCPUs(); err != nil {
+			lac.logger.Warn("failed to read online cpus", zap.Error(err))
+		} else if cpus > 0 {
+			samples = append(samples,
+				collector.Gauge("loadavg.cpus", collector.UnitNone, float64(cpus)),
+				collector.Gauge("loadavg.normalised.load1", collector.UnitNone, la.Load1/float64(cpus)),
+				collector.Gauge("loadavg.normalised.load5", collector.UnitNone, la.Load5/float64(cpus)),
+				collector.Gauge("loadavg.normalised.load15", collector.UnitNone, la.Load15/float64(cpus)),
+			)
+		}
+	}
+
+	up, err := procfs.ReadUptime(procfs.UptimePath)
+	if err != nil {
+		return samples, fmt.Errorf("failed to read uptime: %w", err)
+	}
+	samples = append(samples,
+		collector.Gauge("loadavg.uptime", collector.UnitSeconds, up.Uptime),
+		collector.Cumulative("loadavg.idle", collector.UnitSeconds, up.Idle),
+	)
+	return samples, nil
+}
+
+func init() {
+	sources.Sources["loadavg"] = collector.Factory(NewCollector)
+}
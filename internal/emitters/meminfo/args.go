@@ -0,0 +1,16 @@
+package meminfo
+
+import (
+	"github.com/squizzling/stats/internal/args"
+)
+
+type MemInfoOpts struct {
+	IncludeKey []string `long:"meminfo.include-key" default:"MemTotal,MemFree,MemAvailable,Buffers,Cached,Slab,SReclaimable,SwapTotal,SwapFree,SwapCached,Dirty,Writeback,Shmem,CommitLimit,Committed_AS" description:"keys from meminfo to emit, * for all"`
+	ExcludeKey []string `long:"meminfo.exclude-key"                                                                                                                                           description:"keys from meminfo to not emit"`
+}
+
+func (opts *MemInfoOpts) Validate() []string {
+	opts.IncludeKey = args.Flatten(opts.IncludeKey)
+	opts.ExcludeKey = args.Flatten(opts.ExcludeKey)
+	return nil
+}
@@ -1,50 +1,144 @@
 package meminfo
 
 import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/squizzling/glob/pkg/glob"
 	"go.uber.org/zap"
 
+	"github.com/squizzling/stats/pkg/collector"
 	"github.com/squizzling/stats/pkg/emitter"
+	"github.com/squizzling/stats/pkg/procfs"
 	"github.com/squizzling/stats/pkg/sources"
-	"github.com/squizzling/stats/pkg/statser"
+	"github.com/squizzling/stats/pkg/sysfs"
 )
 
-type MemInfoEmitter struct {
-	logger         *zap.Logger
-	statsClient    statser.Statser
-	trackedMetrics map[string]string
+type MemInfoCollector struct {
+	logger      *zap.Logger
+	keyPatterns glob.Matcher
+	metricNames map[string]string
 }
 
-func NewEmitter(logger *zap.Logger, statsPool statser.Pool, opt emitter.OptProvider) emitter.Emitter {
-	return &MemInfoEmitter{
+func NewCollector(logger *zap.Logger, opt emitter.OptProvider) collector.Collector {
+	opts := opt.Get("meminfo").(*MemInfoOpts)
+	return &MemInfoCollector{
 		logger:      logger,
-		statsClient: statsPool.Host(),
-		trackedMetrics: map[string]string{
-			"mem_total":     "MemTotal",
-			"mem_free":      "MemFree",
-			"mem_available": "MemAvailable",
-			"buffers":       "Buffers",
-			"cached":        "Cached",
-			"slab":          "Slab",
-		},
+		keyPatterns: glob.NewACL(opts.IncludeKey, opts.ExcludeKey, false),
+		metricNames: make(map[string]string),
+	}
+}
+
+// metricName converts a meminfo key to snake case, such as MemTotal to
+// mem_total, and Active(anon) to active_anon.
+func (mic *MemInfoCollector) metricName(key string) string {
+	if name, ok := mic.metricNames[key]; ok {
+		return name
+	}
+	var sb strings.Builder
+	var previous rune
+	for _, r := range key {
+		switch {
+		case r == '(' || r == '_':
+			if previous != '_' {
+				sb.WriteRune('_')
+			}
+			r = '_'
+		case r == ')':
+			continue
+		case unicode.IsUpper(r):
+			if unicode.IsLower(previous) || unicode.IsDigit(previous) {
+				sb.WriteRune('_')
+			}
+			sb.WriteRune(unicode.ToLower(r))
+		default:
+			sb.WriteRune(r)
+		}
+		previous = r
+	}
+	name := sb.String()
+	mic.metricNames[key] = name
+	return name
+}
+
+// unit returns the unit of a meminfo key, which is bytes other than for the
+// huge page counts.
+func unit(key string) collector.Unit {
+	if strings.HasPrefix(key, "HugePages_") {
+		return collector.UnitNone
 	}
+	return collector.UnitBytes
 }
 
-func (mse *MemInfoEmitter) tryEmit(ms *MemInfo, metricSuffix, memStatName string) {
-	metricName := "procmeminfo." + metricSuffix
-	if value, ok := ms.Values[memStatName]; ok {
-		mse.statsClient.Gauge(metricName, value)
-	} else {
-		mse.logger.Warn("not found", zap.String("key", memStatName))
+func (mic *MemInfoCollector) collectValues(samples []collector.Sample, prefix string, values map[string]int64, tags ...string) []collector.Sample {
+	for key, value := range values {
+		if mic.keyPatterns.Match(key) {
+			samples = append(samples, collector.Gauge(prefix+mic.metricName(key), unit(key), float64(value), tags...))
+		}
 	}
+	return samples
 }
 
-func (mse *MemInfoEmitter) Emit() {
-	ms := LoadMemInfo(mse.logger)
-	for metricSuffix, memStatName := range mse.trackedMetrics {
-		mse.tryEmit(ms, metricSuffix, memStatName)
+func (mic *MemInfoCollector) Collect(ctx context.Context, tick time.Time) ([]collector.Sample, error) {
+	ms, err := procfs.ReadMemInfo(procfs.MemInfoPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read meminfo: %w", err)
+	}
+	samples := mic.collectValues(nil, "procmeminfo.", ms.Values)
+
+	if total := ms.Values["MemTotal"]; total > 0 {
+		// MemAvailable is missing before 3.14, where the estimate is cruder.
+		available, ok := ms.Values["MemAvailable"]
+		if !ok {
+			available = ms.Values["MemFree"] + ms.Values["Buffers"] + ms.Values["Cached"]
+		}
+		samples = append(samples,
+			collector.Gauge("procmeminfo.used", collector.UnitBytes, float64(total-available)),
+			collector.Gauge("procmeminfo.available_ratio", collector.UnitNone, float64(available)/float64(total)),
+		)
+	}
+
+	nodes, err := sysfs.ReadNodes()
+	if err != nil {
+		mic.logger.Debug("failed to read numa nodes", zap.Error(err))
+	}
+	for _, node := range nodes {
+		nmi, err := sysfs.ReadNodeMemInfo(node)
+		if err != nil {
+			mic.logger.Warn("failed to read node meminfo", zap.Int("node", node), zap.Error(err))
+			continue
+		}
+		samples = mic.collectValues(samples, "procmeminfo.node.", nmi.Values, "node", strconv.Itoa(node))
+	}
+
+	sizes, err := sysfs.ReadHugePageSizes()
+	if err != nil {
+		mic.logger.Debug("failed to read huge page pools", zap.Error(err))
+	}
+	for _, size := range sizes {
+		// A pool can be briefly unreadable while it's resized.
+		pool, err := sysfs.ReadHugePagePool(size)
+		if err != nil {
+			mic.logger.Warn("failed to read huge page pool", zap.String("size", size), zap.Error(err))
+			continue
+		}
+		add := func(name string, value uint64) {
+			samples = append(samples, collector.Gauge("procmeminfo.hugepages."+name, collector.UnitNone, float64(value), "size", pool.Name))
+		}
+		add("total", pool.Total)
+		add("free", pool.Free)
+		add("reserved", pool.Reserved)
+		add("surplus", pool.Surplus)
+		add("overcommit", pool.Overcommit)
+		samples = append(samples, collector.Gauge("procmeminfo.hugepages.bytes", collector.UnitBytes, float64(pool.Total*pool.Size), "size", pool.Name))
 	}
+	return samples, nil
 }
 
 func init() {
-	sources.Sources["meminfo"] = NewEmitter
+	sources.Sources["meminfo"] = collector.Factory(NewCollector)
 }
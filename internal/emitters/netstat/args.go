@@ -0,0 +1,17 @@
+package netstat
+
+import (
+	"github.com/squizzling/stats/internal/args"
+)
+
+type NetStatOpts struct {
+	IncludeKey []string `long:"netstat.include-key" default:"Tcp.ActiveOpens,Tcp.PassiveOpens,Tcp.AttemptFails,Tcp.EstabResets,Tcp.CurrEstab,Tcp.InSegs,Tcp.OutSegs,Tcp.RetransSegs,Tcp.InErrs,Tcp.OutRsts,TcpExt.ListenOverflows,TcpExt.ListenDrops,TcpExt.TCPTimeouts,TcpExt.TCPSynRetrans,TcpExt.TCPLostRetransmit,Udp.*,Udp6.*,Icmp.InMsgs,Icmp.InErrors,Icmp.OutMsgs,Icmp.OutErrors,Icmp6.InMsgs,Icmp6.InErrors,Icmp6.OutMsgs,Icmp6.OutErrors" description:"counters to emit, as protocol.name, * for all"`
+	ExcludeKey []string `long:"netstat.exclude-key"                                                                                                                                                                                                                                                                                                                                                                                                 description:"counters to not emit"`
+	Containers bool     `long:"netstat.containers"                                                                                                                                                                                                                                                                                                                                                                                                  description:"also emit counters for the network namespace of each container selected by procnetdev.include-container"`
+}
+
+func (opts *NetStatOpts) Validate() []string {
+	opts.IncludeKey = args.Flatten(opts.IncludeKey)
+	opts.ExcludeKey = args.Flatten(opts.ExcludeKey)
+	return nil
+}
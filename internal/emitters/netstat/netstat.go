@@ -0,0 +1,160 @@
+package netstat
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/squizzling/glob/pkg/glob"
+	"go.uber.org/zap"
+
+	"github.com/squizzling/stats/internal/backoff"
+	"github.com/squizzling/stats/internal/docker"
+	"github.com/squizzling/stats/internal/emitters/procnetdev"
+	"github.com/squizzling/stats/pkg/collector"
+	"github.com/squizzling/stats/pkg/emitter"
+	"github.com/squizzling/stats/pkg/procfs"
+	"github.com/squizzling/stats/pkg/sources"
+)
+
+type NetStatCollector struct {
+	logger            *zap.Logger
+	keyPatterns       glob.Matcher
+	containers        bool
+	containerPatterns glob.Matcher
+	dockerBreaker     *backoff.Breaker
+	metricNames       map[string]string
+}
+
+func NewCollector(logger *zap.Logger, opt emitter.OptProvider) collector.Collector {
+	opts := opt.Get("netstat").(*NetStatOpts)
+	// Containers are selected the same way as for their interfaces.
+	pndOpts := opt.Get("procnetdev").(*procnetdev.ProcNetDevOpts)
+	return &NetStatCollector{
+		logger:            logger,
+		keyPatterns:       glob.NewACL(opts.IncludeKey, opts.ExcludeKey, false),
+		containers:        opts.Containers,
+		containerPatterns: glob.NewACL(pndOpts.IncludeContainer, pndOpts.ExcludeContainer, len(pndOpts.IncludeContainer) == 0),
+		dockerBreaker:     backoff.NewBreaker(logger, "netstat.docker", opt.Get("backoff").(*backoff.BackoffOpts)),
+		metricNames:       make(map[string]string),
+	}
+}
+
+// gauges are the values which aren't counters.
+var gauges = map[string]struct{}{
+	"Ip.Forwarding":    {},
+	"Ip.DefaultTTL":    {},
+	"Tcp.RtoAlgorithm": {},
+	"Tcp.RtoMin":       {},
+	"Tcp.RtoMax":       {},
+	"Tcp.MaxConn":      {},
+	"Tcp.CurrEstab":    {},
+}
+
+// readNetStat reads and merges the counters from the net directory of a
+// process, which is the counters of its network namespace.  snmp6 is missing
+// if IPv6 is disabled.
+func readNetStat(procPath string) (procfs.NetStat, error) {
+	ns, err := procfs.ReadNetStat(procPath + "/net/snmp")
+	if err != nil {
+		return nil, err
+	}
+	netstat, err := procfs.ReadNetStat(procPath + "/net/netstat")
+	if err != nil {
+		return nil, err
+	}
+	ns.Merge(netstat)
+	snmp6, err := procfs.ReadNetStat6(procPath + "/net/snmp6")
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	ns.Merge(snmp6)
+	return ns, nil
+}
+
+// metricName converts a protocol.name key to snake case, such as
+// TcpExt.TCPLostRetransmit to tcp_ext.tcp_lost_retransmit.  A run of capitals
+// is kept together, other than its last letter when it starts a word.
+func (nsc *NetStatCollector) metricName(key string) string {
+	if name, ok := nsc.metricNames[key]; ok {
+		return name
+	}
+	runes := []rune(key)
+	var sb strings.Builder
+	for i, r := range runes {
+		if unicode.IsUpper(r) && i > 0 {
+			previous := runes[i-1]
+			nextLower := i+1 < len(runes) && unicode.IsLower(runes[i+1])
+			if unicode.IsLower(previous) || unicode.IsDigit(previous) || unicode.IsUpper(previous) && nextLower {
+				sb.WriteRune('_')
+			}
+		}
+		sb.WriteRune(unicode.ToLower(r))
+	}
+	name := sb.String()
+	nsc.metricNames[key] = name
+	return name
+}
+
+func (nsc *NetStatCollector) collectNetStat(samples []collector.Sample, prefix string, ns procfs.NetStat, tags ...string) []collector.Sample {
+	for protocol, counters := range ns {
+		for name, value := range counters {
+			key := protocol + "." + name
+			// Keys are matched by their kernel names, which are what the
+			// documentation and other tools use.
+			if !nsc.keyPatterns.Match(key) {
+				continue
+			}
+			name := prefix + nsc.metricName(key)
+			if _, ok := gauges[key]; ok {
+				samples = append(samples, collector.Gauge(name, collector.UnitNone, float64(value), tags...))
+			} else {
+				samples = append(samples, collector.Cumulative(name, collector.UnitNone, float64(value), tags...))
+			}
+		}
+	}
+	return samples
+}
+
+func (nsc *NetStatCollector) Collect(ctx context.Context, tick time.Time) ([]collector.Sample, error) {
+	ns, err := readNetStat("/proc")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read netstat: %w", err)
+	}
+	samples := nsc.collectNetStat(nil, "netstat.", ns)
+
+	if nsc.containers && nsc.dockerBreaker.Allow() {
+		samples = nsc.collectContainers(ctx, samples)
+	}
+	return samples, nil
+}
+
+// collectContainers adds the counters for each selected container.  A
+// container which exits between being listed and being read is skipped.
+func (nsc *NetStatCollector) collectContainers(ctx context.Context, samples []collector.Sample) []collector.Sample {
+	containers, err := docker.RunningContainers(ctx, nsc.logger)
+	if nsc.dockerBreaker.Check("list-containers", err) {
+		return samples
+	}
+	nsc.dockerBreaker.Success()
+	for _, c := range containers {
+		if !nsc.containerPatterns.Match(c.Name) {
+			continue
+		}
+		ns, err := readNetStat("/proc/" + strconv.Itoa(c.Pid))
+		if err != nil {
+			nsc.logger.Warn("failed to read container netstat", zap.String("container", c.Name), zap.Error(err))
+			continue
+		}
+		samples = nsc.collectNetStat(samples, "netstat.docker.", ns, "container", c.Name)
+	}
+	return samples
+}
+
+func init() {
+	sources.Sources["netstat"] = collector.Factory(NewCollector)
+}
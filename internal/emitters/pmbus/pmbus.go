@@ -1,10 +1,12 @@
 package pmbus
 
 import (
+	"errors"
 	"fmt"
 
 	"go.uber.org/zap"
 
+	"github.com/squizzling/stats/internal/backoff"
 	"github.com/squizzling/stats/pkg/emitter"
 	"github.com/squizzling/stats/pkg/sources"
 	"github.com/squizzling/stats/pkg/statser"
@@ -14,6 +16,7 @@ type CorsairEmitter struct {
 	logger *zap.Logger
 
 	statsPool statser.Pool
+	breaker   *backoff.Breaker
 }
 
 const vidCorsair = 0x1b1c
@@ -21,33 +24,54 @@ const pidHX750i = 0x1c05
 const pidHX1000i = 0x1c07
 
 func (ce *CorsairEmitter) Emit() {
+	defer ce.breaker.Report(ce.statsPool)
+	if !ce.breaker.Allow() {
+		return
+	}
+
 	dev := newPmbusDevice(ce.logger, vidCorsair, pidHX750i)
 	if dev == nil {
+		ce.breaker.Failure("open", errors.New("device not found"))
 		return
 	}
 	defer dev.close()
 
+	read := func(page byte, command byte) (float64, bool) {
+		b := dev.execReadFromPage(page, command)
+		if len(b) < 4 {
+			ce.breaker.Failure("read", fmt.Errorf("short read of %#x from page %d", command, page))
+			return 0, false
+		}
+		return linearToFloat64(b[2:4]), true
+	}
+	gauge := func(c statser.Statser, name string, page byte, command byte) bool {
+		v, ok := read(page, command)
+		if ok {
+			c.Gauge(name, v)
+		}
+		return ok
+	}
+
 	client := ce.statsPool.Host()
-	b := dev.execReadFromPage(0, pmbusReadTemperature1)
-	ce.statsPool.Host("sensor", "1").Gauge("pmbus.temperature", linearToFloat64(b[2:4]))
-	b = dev.execReadFromPage(0, pmbusReadTemperature2)
-	ce.statsPool.Host("sensor", "2").Gauge("pmbus.temperature", linearToFloat64(b[2:4]))
-	b = dev.execReadFromPage(0, pmbusReadFanSpeed1)
-	ce.statsPool.Host("fan", "1").Gauge("pmbus.fanspeed", linearToFloat64(b[2:4]))
-	b = dev.execReadFromPage(0, pmbusReadVin)
-	client.Gauge("pmbus.voltage_in", linearToFloat64(b[2:4]))
-	b = dev.execReadFromPage(0, pmbusMfrSpecific30)
-	client.Gauge("pmbus.power_in", linearToFloat64(b[2:4]))
+	ok := gauge(ce.statsPool.Host("sensor", "1"), "pmbus.temperature", 0, pmbusReadTemperature1) &&
+		gauge(ce.statsPool.Host("sensor", "2"), "pmbus.temperature", 0, pmbusReadTemperature2) &&
+		gauge(ce.statsPool.Host("fan", "1"), "pmbus.fanspeed", 0, pmbusReadFanSpeed1) &&
+		gauge(client, "pmbus.voltage_in", 0, pmbusReadVin) &&
+		gauge(client, "pmbus.power_in", 0, pmbusMfrSpecific30)
+	if !ok {
+		return
+	}
 
 	for page, name := range []string{"12", "5", "3.3"} {
 		client = ce.statsPool.Host("rail", name)
-		b = dev.execReadFromPage(byte(page), pmbusReadVOut)
-		client.Gauge("pmbus.voltage_out", linearToFloat64(b[2:4]))
-		b = dev.execReadFromPage(byte(page), pmbusReadIOut)
-		client.Gauge("pmbus.current_out", linearToFloat64(b[2:4]))
-		b = dev.execReadFromPage(byte(page), pmbusReadPOut)
-		client.Gauge("pmbus.power_out", linearToFloat64(b[2:4]))
+		ok := gauge(client, "pmbus.voltage_out", byte(page), pmbusReadVOut) &&
+			gauge(client, "pmbus.current_out", byte(page), pmbusReadIOut) &&
+			gauge(client, "pmbus.power_out", byte(page), pmbusReadPOut)
+		if !ok {
+			return
+		}
 	}
+	ce.breaker.Success()
 }
 
 func NewEmitter(logger *zap.Logger, statsPool statser.Pool, opt emitter.OptProvider) emitter.Emitter {
@@ -57,11 +81,12 @@ func NewEmitter(logger *zap.Logger, statsPool statser.Pool, opt emitter.OptProvi
 	}
 	// []byte{0xfe, 0x3, 0x48, 0x58, 0x31, 0x30, 0x30, 0x30, 0x69, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0}
 	b := dev.execWriteAddress(0xfe, pmbusClearFaults)
-	fmt.Printf("%#v\n", b)
+	logger.Debug("cleared faults", zap.Binary("response", b))
 	dev.close()
 	return &CorsairEmitter{
 		logger:    logger,
 		statsPool: statsPool,
+		breaker:   backoff.NewBreaker(logger, "pmbus", opt.Get("backoff").(*backoff.BackoffOpts)),
 	}
 }
 
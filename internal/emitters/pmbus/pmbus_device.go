@@ -76,7 +76,7 @@ func (pm *pmbusDevice) selectPage(page byte) bool {
 	buffer := []byte{2, pmbusPage, page}
 	pm.write(buffer)
 	buffer = pm.read()
-	if buffer[0] != 2 || buffer[2] != page {
+	if len(buffer) < 3 || buffer[0] != 2 || buffer[2] != page {
 		pm.logger.Error("switch page failed", zap.Int("page", int(page)), zap.ByteString("result", trimZero(buffer)))
 		return false
 	}
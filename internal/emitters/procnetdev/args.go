@@ -1,14 +1,52 @@
 package procnetdev
 
 import (
+	"fmt"
+
 	"github.com/squizzling/stats/internal/args"
 )
 
 type ProcNetDevOpts struct {
-	IncludeInterface []string `long:"procnetdev.include-interface"`
-	ExcludeInterface []string `long:"procnetdev.exclude-interface"`
-	IncludeContainer []string `long:"procnetdev.include-container"`
-	ExcludeContainer []string `long:"procnetdev.exclude-container"`
+	IncludeInterface          []string `long:"procnetdev.include-interface"`
+	ExcludeInterface          []string `long:"procnetdev.exclude-interface"`
+	IncludeContainer          []string `long:"procnetdev.include-container"`
+	ExcludeContainer          []string `long:"procnetdev.exclude-container"`
+	IncludeContainerInterface []string `long:"procnetdev.include-container-interface" default:"eth*"                         description:"interfaces to emit inside containers"`
+	ExcludeContainerInterface []string `long:"procnetdev.exclude-container-interface"                                        description:"interfaces to not emit inside containers"`
+	HostCounters              []string `long:"procnetdev.host-counters"               default:"bytes,packets,errors,dropped" description:"counter groups to emit for host interfaces, from bytes, packets, errors, dropped, overrun, frame, compressed, multicast, collisions, and carrier"`
+	ContainerCounters         []string `long:"procnetdev.container-counters"          default:"bytes,packets"                description:"counter groups to emit for container interfaces"`
+	Link                      bool     `long:"procnetdev.link"                                                               description:"also emit link state and utilisation of host interfaces from /sys/class/net, and tag bond and bridge members with their master"`
+
+	hostCounters      map[string]struct{}
+	containerCounters map[string]struct{}
+}
+
+// counterGroups are the groups of counters which can be selected.  Each is
+// emitted for rx and tx where the kernel has both.
+var counterGroups = map[string]struct{}{
+	"bytes":      {},
+	"packets":    {},
+	"errors":     {},
+	"dropped":    {},
+	"overrun":    {},
+	"frame":      {},
+	"compressed": {},
+	"multicast":  {},
+	"collisions": {},
+	"carrier":    {},
+}
+
+func parseCounterGroups(option string, groups []string) (map[string]struct{}, []string) {
+	var errs []string
+	selected := make(map[string]struct{})
+	for _, group := range groups {
+		if _, ok := counterGroups[group]; !ok {
+			errs = append(errs, fmt.Sprintf("%s: unknown counter group %s", option, group))
+			continue
+		}
+		selected[group] = struct{}{}
+	}
+	return selected, errs
 }
 
 func (opts *ProcNetDevOpts) Validate() []string {
@@ -17,5 +55,13 @@ func (opts *ProcNetDevOpts) Validate() []string {
 	opts.ExcludeInterface = args.Flatten(opts.ExcludeInterface)
 	opts.IncludeContainer = args.Flatten(opts.IncludeContainer)
 	opts.ExcludeContainer = args.Flatten(opts.ExcludeContainer)
-	return nil
+	opts.IncludeContainerInterface = args.Flatten(opts.IncludeContainerInterface)
+	opts.ExcludeContainerInterface = args.Flatten(opts.ExcludeContainerInterface)
+
+	var errs, groupErrs []string
+	opts.hostCounters, groupErrs = parseCounterGroups("procnetdev.host-counters", args.Flatten(opts.HostCounters))
+	errs = append(errs, groupErrs...)
+	opts.containerCounters, groupErrs = parseCounterGroups("procnetdev.container-counters", args.Flatten(opts.ContainerCounters))
+	errs = append(errs, groupErrs...)
+	return errs
 }
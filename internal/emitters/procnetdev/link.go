@@ -0,0 +1,87 @@
+package procnetdev
+
+import (
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/squizzling/stats/pkg/collector"
+	"github.com/squizzling/stats/pkg/procfs"
+	"github.com/squizzling/stats/pkg/statser"
+	"github.com/squizzling/stats/pkg/sysfs"
+)
+
+// linkBytes is the byte counters of an interface at a tick, to calculate
+// utilisation at the next tick.
+type linkBytes struct {
+	tick    time.Time
+	rxBytes uint64
+	txBytes uint64
+}
+
+// readLink reads the link state of a host interface, returning nil if it
+// can't be read, such as when the interface was removed since /proc/net/dev
+// was read.
+func (pnde *ProcNetDevEmitter) readLink(name string) *sysfs.NetLink {
+	link, err := sysfs.ReadNetLink(name)
+	if err != nil {
+		pnde.logger.Warn("failed to read link", zap.String("interface", name), zap.Error(err))
+		return nil
+	}
+	return link
+}
+
+// linkTags returns the tags for an interface which is a member of a bond or
+// bridge.
+func linkTags(link *sysfs.NetLink) []string {
+	if link == nil || link.Master == "" {
+		return nil
+	}
+	return []string{"master", link.Master, "master_kind", link.MasterKind}
+}
+
+func boolGauge(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+// emitLink emits the link state of an interface, and its utilisation since
+// the previous tick if the speed is known.
+func (pnde *ProcNetDevEmitter) emitLink(c statser.Statser, tick time.Time, i *procfs.NetDevInterface, link *sysfs.NetLink, current map[string]*linkBytes) {
+	c.Gauge("net.link.mtu", link.MTU)
+	// Loopback and many virtual interfaces have an unknown state, but are
+	// usable if they have a carrier.
+	c.Gauge("net.link.up", boolGauge(link.OperState == "up" || link.OperState == "unknown" && link.Carrier == 1))
+	collector.Describe(c, collector.KindCumulative, collector.UnitNone).Gauge("net.link.carrier_changes", link.CarrierChanges)
+	if link.Carrier >= 0 {
+		c.Gauge("net.link.carrier", link.Carrier)
+	}
+	if link.Duplex != "unknown" {
+		c.Gauge("net.link.full_duplex", boolGauge(link.Duplex == "full"))
+	}
+
+	lb := &linkBytes{
+		tick:    tick,
+		rxBytes: i.RxBytes,
+		txBytes: i.TxBytes,
+	}
+	current[i.Name] = lb
+	if link.Speed <= 0 {
+		return
+	}
+	speed := float64(link.Speed) * 1000 * 1000 // bits per second
+	c.Gauge("net.link.speed", speed)
+
+	previous := pnde.linkBytes[i.Name]
+	if previous == nil || lb.rxBytes < previous.rxBytes || lb.txBytes < previous.txBytes {
+		return
+	}
+	seconds := lb.tick.Sub(previous.tick).Seconds()
+	if seconds <= 0 {
+		return
+	}
+	c.Gauge("net.link.utilisation.rx", 100*8*float64(lb.rxBytes-previous.rxBytes)/seconds/speed)
+	c.Gauge("net.link.utilisation.tx", 100*8*float64(lb.txBytes-previous.txBytes)/seconds/speed)
+}
@@ -1,70 +1,23 @@
 package procnetdev
 
 import (
-	"strings"
+	"go.uber.org/zap"
 
 	"github.com/squizzling/glob/pkg/glob"
 
-	"github.com/squizzling/stats/internal/iio"
+	"github.com/squizzling/stats/pkg/procfs"
 )
 
-type Interface struct {
-	name         string
-	rxBytes      uint64
-	rxPackets    uint64
-	rxErrors     uint64
-	rxDropped    uint64
-	rxOverrun    uint64
-	rxFrame      uint64
-	rxCompressed uint64
-	rxMulticast  uint64
-	txBytes      uint64
-	txPackets    uint64
-	txErrors     uint64
-	txDropped    uint64
-	txOverrun    uint64
-	txCollisions uint64
-	txCarrier    uint64
-	txCompressed uint64
-}
-
-func parseInterface(line []byte) *Interface {
-	iface := &Interface{}
-
-	c := iio.NewChunker(line)
-	iface.name = strings.TrimRight(c.NextString(), ":")
-	iface.rxBytes = c.NextUint64()
-	iface.rxPackets = c.NextUint64()
-	iface.rxErrors = c.NextUint64()
-	iface.rxDropped = c.NextUint64()
-	iface.rxOverrun = c.NextUint64()
-	iface.rxFrame = c.NextUint64()
-	iface.rxCompressed = c.NextUint64()
-	iface.rxMulticast = c.NextUint64()
-	iface.txBytes = c.NextUint64()
-	iface.txPackets = c.NextUint64()
-	iface.txErrors = c.NextUint64()
-	iface.txDropped = c.NextUint64()
-	iface.txOverrun = c.NextUint64()
-	iface.txCollisions = c.NextUint64()
-	iface.txCarrier = c.NextUint64()
-	iface.txCompressed = c.NextUint64()
-
-	if c.Err() != nil {
+func (pnde *ProcNetDevEmitter) loadInterfaceStats(filename string, m glob.Matcher) []*procfs.NetDevInterface {
+	ifaces, err := procfs.ReadNetDev(filename)
+	if err != nil {
+		pnde.logger.Warn("failed to read net/dev", zap.String("file", filename), zap.Error(err))
 		return nil
 	}
-
-	return iface
-}
-
-func (pnde *ProcNetDevEmitter) loadInterfaceStats(filename string, m glob.Matcher) []*Interface {
-	var is []*Interface
-	lines := iio.SplitLines(iio.ReadEntireFile(pnde.logger, filename))
-	for _, line := range lines {
-		if i := parseInterface(line); i != nil {
-			if m.Match(i.name) {
-				is = append(is, i)
-			}
+	var is []*procfs.NetDevInterface
+	for _, i := range ifaces {
+		if m.Match(i.Name) {
+			is = append(is, i)
 		}
 	}
 	return is
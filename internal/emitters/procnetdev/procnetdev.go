@@ -1,15 +1,22 @@
 package procnetdev
 
 import (
+	"context"
 	"fmt"
+	"time"
 
 	"go.uber.org/zap"
 
 	"github.com/squizzling/glob/pkg/glob"
 
+	"github.com/squizzling/stats/internal/backoff"
+	"github.com/squizzling/stats/internal/docker"
+	"github.com/squizzling/stats/pkg/collector"
 	"github.com/squizzling/stats/pkg/emitter"
+	"github.com/squizzling/stats/pkg/procfs"
 	"github.com/squizzling/stats/pkg/sources"
 	"github.com/squizzling/stats/pkg/statser"
+	"github.com/squizzling/stats/pkg/sysfs"
 )
 
 type ProcNetDevEmitter struct {
@@ -18,6 +25,12 @@ type ProcNetDevEmitter struct {
 	hostInterfacePatterns glob.Matcher
 	containerPatterns     glob.Matcher
 	ethMatcher            glob.Matcher
+	hostCounters          map[string]struct{}
+	containerCounters     map[string]struct{}
+	dockerBreaker         *backoff.Breaker
+
+	link      bool
+	linkBytes map[string]*linkBytes
 }
 
 func NewEmitter(logger *zap.Logger, statsPools statser.Pool, opt emitter.OptProvider) emitter.Emitter {
@@ -27,67 +40,110 @@ func NewEmitter(logger *zap.Logger, statsPools statser.Pool, opt emitter.OptProv
 		statsPool:             statsPools,
 		hostInterfacePatterns: glob.NewACL(opts.IncludeInterface, opts.ExcludeInterface, len(opts.IncludeInterface) == 0),
 		containerPatterns:     glob.NewACL(opts.IncludeContainer, opts.ExcludeContainer, len(opts.IncludeContainer) == 0),
-		ethMatcher:            glob.NewACL([]string{"eth*"}, nil, false),
+		ethMatcher:            glob.NewACL(opts.IncludeContainerInterface, opts.ExcludeContainerInterface, len(opts.IncludeContainerInterface) == 0),
+		hostCounters:          opts.hostCounters,
+		containerCounters:     opts.containerCounters,
+		dockerBreaker:         backoff.NewBreaker(logger, "docker", opt.Get("backoff").(*backoff.BackoffOpts)),
+		link:                  opts.Link,
+		linkBytes:             make(map[string]*linkBytes),
 	}
 
 	return pnde
 }
 
 func (pnde *ProcNetDevEmitter) Emit() {
-	ids := getDockerContainerIDs()
-	for _, id := range ids {
-		d := getDockerContainerDetail(id)
-		if d.Id != id {
-			pnde.logger.Warn("unexpected id", zap.String("original", id), zap.String("found", d.Id))
-			continue
-		}
-		if d.State.Status != "running" {
-			pnde.logger.Info("not running, skipping", zap.String("container", id), zap.String("state", d.State.Status))
-			continue
-		}
-		if !d.State.Running {
-			pnde.logger.Info("not running, skipping", zap.String("container", id))
-			continue
+	pnde.EmitTick(context.Background(), time.Now())
+}
+
+func (pnde *ProcNetDevEmitter) EmitTick(ctx context.Context, tick time.Time) {
+	if pnde.dockerBreaker.Allow() {
+		pnde.emitContainers(ctx, tick)
+	}
+	pnde.dockerBreaker.Report(pnde.statsPool)
+
+	// Only the interfaces seen this tick are kept, so removed interfaces
+	// don't accumulate.
+	currentLinkBytes := make(map[string]*linkBytes)
+	is := pnde.loadInterfaceStats("/proc/net/dev", pnde.hostInterfacePatterns)
+	for _, i := range is {
+		var link *sysfs.NetLink
+		if pnde.link {
+			link = pnde.readLink(i.Name)
 		}
-		if d.State.Pid == 0 {
-			pnde.logger.Info("pid is 0, skipping", zap.String("container", id))
-			continue
+		c := statser.At(pnde.statsPool.Host(append([]string{"interface", i.Name}, linkTags(link)...)...), tick)
+		pnde.emitInterfaceStats(c, "net.host.", pnde.hostCounters, i)
+		if link != nil {
+			pnde.emitLink(c, tick, i, link, currentLinkBytes)
 		}
+	}
+	pnde.linkBytes = currentLinkBytes
+}
+
+// emitContainers emits the interfaces of each running container.  A failure
+// to talk to Docker skips the containers, but not the host interfaces.
+func (pnde *ProcNetDevEmitter) emitContainers(ctx context.Context, tick time.Time) {
+	containers, err := docker.RunningContainers(ctx, pnde.logger)
+	if pnde.dockerBreaker.Check("list-containers", err) {
+		return
+	}
+	for _, d := range containers {
 		if !pnde.containerPatterns.Match(d.Name) {
-			pnde.logger.Debug("ignored container, skipping", zap.String("container", id))
+			pnde.logger.Debug("ignored container, skipping", zap.String("container", d.ID))
 			continue
 		}
-		is := pnde.loadInterfaceStats(fmt.Sprintf("/proc/%d/net/dev", d.State.Pid), pnde.ethMatcher)
+		is := pnde.loadInterfaceStats(fmt.Sprintf("/proc/%d/net/dev", d.Pid), pnde.ethMatcher)
 		for _, i := range is {
-			c := pnde.statsPool.Host("interface", i.name, "container", d.Name)
-			pnde.emitInterfaceStats(c, "net.docker.", i)
+			c := statser.At(pnde.statsPool.Host("interface", i.Name, "container", d.Name), tick)
+			pnde.emitInterfaceStats(c, "net.docker.", pnde.containerCounters, i)
 		}
 	}
-
-	is := pnde.loadInterfaceStats("/proc/net/dev", pnde.hostInterfacePatterns)
-	for _, i := range is {
-		c := pnde.statsPool.Host("interface", i.name)
-		pnde.emitInterfaceStats(c, "net.host.", i)
-	}
+	pnde.dockerBreaker.Success()
 }
 
-func (pnde *ProcNetDevEmitter) emitInterfaceStats(c statser.Statser, prefix string, i *Interface) {
-	c.Gauge(prefix+"rx.bytes", i.rxBytes)
-	c.Gauge(prefix+"rx.packets", i.rxPackets)
-	//c.Gauge(prefix+"rx.errors", i.rxErrors)
-	//c.Gauge(prefix+"rx.dropped", i.rxDropped)
-	//c.Gauge(prefix+"rx.overrun", i.rxOverrun)
-	//c.Gauge(prefix+"rx.frame", i.rxFrame)
-	//c.Gauge(prefix+"rx.compressed", i.rxCompressed)
-	//c.Gauge(prefix+"rx.multicast", i.rxMulticast)
-	c.Gauge(prefix+"tx.bytes", i.txBytes)
-	c.Gauge(prefix+"tx.packets", i.txPackets)
-	//c.Gauge(prefix+"tx.errors", i.txErrors)
-	//c.Gauge(prefix+"tx.dropped", i.txDropped)
-	//c.Gauge(prefix+"tx.overrun", i.txOverrun)
-	//c.Gauge(prefix+"tx.collisions", i.txCollisions)
-	//c.Gauge(prefix+"tx.carrier", i.txCarrier)
-	//c.Gauge(prefix+"tx.compressed", i.txCompressed)
+func (pnde *ProcNetDevEmitter) emitInterfaceStats(c statser.Statser, prefix string, groups map[string]struct{}, i *procfs.NetDevInterface) {
+	has := func(group string) bool {
+		_, ok := groups[group]
+		return ok
+	}
+	// Every field is a cumulative counter, written as a gauge.
+	bytes := collector.Describe(c, collector.KindCumulative, collector.UnitBytes)
+	c = collector.Describe(c, collector.KindCumulative, collector.UnitNone)
+	if has("bytes") {
+		bytes.Gauge(prefix+"rx.bytes", i.RxBytes)
+		bytes.Gauge(prefix+"tx.bytes", i.TxBytes)
+	}
+	if has("packets") {
+		c.Gauge(prefix+"rx.packets", i.RxPackets)
+		c.Gauge(prefix+"tx.packets", i.TxPackets)
+	}
+	if has("errors") {
+		c.Gauge(prefix+"rx.errors", i.RxErrors)
+		c.Gauge(prefix+"tx.errors", i.TxErrors)
+	}
+	if has("dropped") {
+		c.Gauge(prefix+"rx.dropped", i.RxDropped)
+		c.Gauge(prefix+"tx.dropped", i.TxDropped)
+	}
+	if has("overrun") {
+		c.Gauge(prefix+"rx.overrun", i.RxOverrun)
+		c.Gauge(prefix+"tx.overrun", i.TxOverrun)
+	}
+	if has("frame") {
+		c.Gauge(prefix+"rx.frame", i.RxFrame)
+	}
+	if has("compressed") {
+		c.Gauge(prefix+"rx.compressed", i.RxCompressed)
+		c.Gauge(prefix+"tx.compressed", i.TxCompressed)
+	}
+	if has("multicast") {
+		c.Gauge(prefix+"rx.multicast", i.RxMulticast)
+	}
+	if has("collisions") {
+		c.Gauge(prefix+"tx.collisions", i.TxCollisions)
+	}
+	if has("carrier") {
+		c.Gauge(prefix+"tx.carrier", i.TxCarrier)
+	}
 }
 
 func init() {
@@ -0,0 +1,20 @@
+package procstat
+
+import (
+	"github.com/squizzling/stats/internal/args"
+)
+
+type ProcStatOpts struct {
+	IncludeCPU  []string `long:"procstat.include-cpu"  description:"CPU numbers to emit per-CPU metrics for"`
+	ExcludeCPU  []string `long:"procstat.exclude-cpu"  description:"CPU numbers to not emit per-CPU metrics for"`
+	IncludeMode []string `long:"procstat.include-mode" description:"CPU modes to emit, such as user, system, or busy"`
+	ExcludeMode []string `long:"procstat.exclude-mode" description:"CPU modes to not emit"`
+}
+
+func (opts *ProcStatOpts) Validate() []string {
+	opts.IncludeCPU = args.Flatten(opts.IncludeCPU)
+	opts.ExcludeCPU = args.Flatten(opts.ExcludeCPU)
+	opts.IncludeMode = args.Flatten(opts.IncludeMode)
+	opts.ExcludeMode = args.Flatten(opts.ExcludeMode)
+	return nil
+}
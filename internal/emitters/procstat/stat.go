@@ -1,40 +1,49 @@
 package procstat
 
 import (
+	"context"
 	"fmt"
 	"strconv"
+	"time"
 
+	"github.com/squizzling/glob/pkg/glob"
 	"go.uber.org/zap"
 
+	"github.com/squizzling/stats/pkg/collector"
 	"github.com/squizzling/stats/pkg/emitter"
+	"github.com/squizzling/stats/pkg/procfs"
 	"github.com/squizzling/stats/pkg/sources"
-	"github.com/squizzling/stats/pkg/statser"
+	"github.com/squizzling/stats/pkg/sysfs"
 )
 
-type ProcStatEmitter struct {
-	logger    *zap.Logger
-	statsPool statser.Pool
+type ProcStatCollector struct {
+	logger       *zap.Logger
+	cpuPatterns  glob.Matcher
+	modePatterns glob.Matcher
+
+	topology map[int][]string
+	previous *procfs.Stat
 }
 
-func NewEmitter(logger *zap.Logger, statsPools statser.Pool, opt emitter.OptProvider) emitter.Emitter {
-	return &ProcStatEmitter{
-		logger:    logger,
-		statsPool: statsPools,
+func NewCollector(logger *zap.Logger, opt emitter.OptProvider) collector.Collector {
+	opts := opt.Get("procstat").(*ProcStatOpts)
+	return &ProcStatCollector{
+		logger:       logger,
+		cpuPatterns:  glob.NewACL(opts.IncludeCPU, opts.ExcludeCPU, len(opts.IncludeCPU) == 0),
+		modePatterns: glob.NewACL(opts.IncludeMode, opts.ExcludeMode, len(opts.IncludeMode) == 0),
+		topology:     make(map[int][]string),
 	}
 }
 
-func emitProcStatCpu(c statser.Statser, s string, cpu *ProcStatCpu) {
-	c.Gauge(fmt.Sprintf("procstat.cpu.%s.user", s), cpu.User)
-	c.Gauge(fmt.Sprintf("procstat.cpu.%s.nice", s), cpu.Nice)
-	c.Gauge(fmt.Sprintf("procstat.cpu.%s.system", s), cpu.System)
-	c.Gauge(fmt.Sprintf("procstat.cpu.%s.idle", s), cpu.Idle)
-	c.Gauge(fmt.Sprintf("procstat.cpu.%s.iowait", s), cpu.IoWait)
-	c.Gauge(fmt.Sprintf("procstat.cpu.%s.irq", s), cpu.Irq)
-	c.Gauge(fmt.Sprintf("procstat.cpu.%s.softirq", s), cpu.SoftIrq)
-	c.Gauge(fmt.Sprintf("procstat.cpu.%s.steal", s), cpu.Steal)
-	c.Gauge(fmt.Sprintf("procstat.cpu.%s.guest", s), cpu.Guest)
-	c.Gauge(fmt.Sprintf("procstat.cpu.%s.guestnice", s), cpu.GuestNice)
+type cpuMode struct {
+	name  string
+	value int64
+}
 
+// cpuModes returns the time spent in each mode, followed by the active and
+// total sums.  The kernel already counts guest and guestnice in user and
+// nice, so they're reported, but left out of the sums.
+func cpuModes(cpu *procfs.CPUStat) []cpuMode {
 	active := 0 + // because gofmt is awesome
 		cpu.User +
 		cpu.Nice +
@@ -42,24 +51,130 @@ func emitProcStatCpu(c statser.Statser, s string, cpu *ProcStatCpu) {
 		cpu.IoWait +
 		cpu.Irq +
 		cpu.SoftIrq +
-		cpu.Steal +
-		cpu.Guest +
-		cpu.GuestNice
-	total := active + cpu.Idle
-	c.Gauge(fmt.Sprintf("procstat.cpu.%s.active", s), active)
-	c.Gauge(fmt.Sprintf("procstat.cpu.%s.total", s), total)
+		cpu.Steal
+	return []cpuMode{
+		{"user", cpu.User},
+		{"nice", cpu.Nice},
+		{"system", cpu.System},
+		{"idle", cpu.Idle},
+		{"iowait", cpu.IoWait},
+		{"irq", cpu.Irq},
+		{"softirq", cpu.SoftIrq},
+		{"steal", cpu.Steal},
+		{"guest", cpu.Guest},
+		{"guestnice", cpu.GuestNice},
+		{"active", active},
+		{"total", active + cpu.Idle},
+	}
+}
+
+func (psc *ProcStatCollector) collectCPU(samples []collector.Sample, s string, cpu *procfs.CPUStat, previous *procfs.CPUStat, tags ...string) []collector.Sample {
+	modes := cpuModes(cpu)
+	for _, mode := range modes {
+		if psc.modePatterns.Match(mode.name) {
+			samples = append(samples, collector.Cumulative(fmt.Sprintf("procstat.cpu.%s.%s", s, mode.name), collector.UnitJiffies, float64(mode.value), tags...))
+		}
+	}
+
+	if previous == nil {
+		return samples
+	}
+	previousModes := cpuModes(previous)
+	total := modes[len(modes)-1].value - previousModes[len(modes)-1].value
+	if total <= 0 {
+		// No time has passed, or the counters went backwards after a CPU was
+		// brought back online.
+		return samples
+	}
+	for i, mode := range modes[:len(modes)-1] {
+		name := mode.name
+		if name == "active" {
+			name = "busy"
+		}
+		delta := mode.value - previousModes[i].value
+		if delta < 0 || !psc.modePatterns.Match(name) {
+			continue
+		}
+		samples = append(samples, collector.Gauge(fmt.Sprintf("procstat.utilisation.%s.%s", s, name), collector.UnitPercent, 100*float64(delta)/float64(total), tags...))
+	}
+	return samples
 }
 
-func (pse *ProcStatEmitter) Emit() {
-	ps := LoadProcStat(pse.logger)
+// cpuTags returns the tags for a CPU, including where it sits in the machine
+// if the kernel exposes it.
+func (psc *ProcStatCollector) cpuTags(cpu int) []string {
+	if tags, ok := psc.topology[cpu]; ok {
+		return tags
+	}
+	tags := []string{"cpu", strconv.Itoa(cpu)}
+	if t, err := sysfs.ReadCPUTopology(cpu); err != nil {
+		psc.logger.Debug("failed to read cpu topology", zap.Int("cpu", cpu), zap.Error(err))
+	} else {
+		tags = append(tags, "core", strconv.Itoa(t.CoreID), "package", strconv.Itoa(t.PackageID))
+		if t.NUMANode >= 0 {
+			tags = append(tags, "numa_node", strconv.Itoa(t.NUMANode))
+		}
+	}
+	psc.topology[cpu] = tags
+	return tags
+}
+
+func (psc *ProcStatCollector) Collect(ctx context.Context, tick time.Time) ([]collector.Sample, error) {
+	ps, err := procfs.ReadStat(procfs.StatPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read stat: %w", err)
+	}
+	for _, err := range ps.Skipped {
+		psc.logger.Warn("skipped cpu line", zap.Error(err))
+	}
+	previous := psc.previous
+	if previous == nil {
+		previous = &procfs.Stat{}
+	}
+	psc.previous = ps
+
+	var samples []collector.Sample
 	if ps.CPUTotal != nil {
-		emitProcStatCpu(pse.statsPool.Host(), "total", ps.CPUTotal)
+		samples = psc.collectCPU(samples, "total", ps.CPUTotal, previous.CPUTotal)
 	}
 	for idx, perCPUStats := range ps.CPUs {
-		emitProcStatCpu(pse.statsPool.Host("cpu", strconv.Itoa(idx)), "per", perCPUStats)
+		if !psc.cpuPatterns.Match(strconv.Itoa(idx)) {
+			continue
+		}
+		samples = psc.collectCPU(samples, "per", perCPUStats, previous.CPUs[idx], psc.cpuTags(idx)...)
+	}
+
+	samples = append(samples,
+		collector.Cumulative("procstat.interrupts", collector.UnitOperations, float64(ps.Interrupts)),
+		collector.Cumulative("procstat.context_switches", collector.UnitOperations, float64(ps.ContextSwitches)),
+		collector.Cumulative("procstat.forks", collector.UnitOperations, float64(ps.Forks)),
+		collector.Gauge("procstat.procs.running", collector.UnitNone, float64(ps.ProcsRunning)),
+		collector.Gauge("procstat.procs.blocked", collector.UnitNone, float64(ps.ProcsBlocked)),
+		collector.Gauge("procstat.boot_time", collector.UnitSeconds, float64(ps.BootTime)),
+	)
+	if ps.SoftIRQ != nil {
+		samples = collectProcStatSoftIRQ(samples, ps.SoftIRQ)
+	}
+	return samples, nil
+}
+
+func collectProcStatSoftIRQ(samples []collector.Sample, softirq *procfs.SoftIRQStat) []collector.Sample {
+	add := func(softirqType string, value uint64) {
+		samples = append(samples, collector.Cumulative("procstat.softirq", collector.UnitOperations, float64(value), "type", softirqType))
 	}
+	add("hi", softirq.Hi)
+	add("timer", softirq.Timer)
+	add("net_tx", softirq.NetTx)
+	add("net_rx", softirq.NetRx)
+	add("block", softirq.Block)
+	add("irq_poll", softirq.IRQPoll)
+	add("tasklet", softirq.Tasklet)
+	add("sched", softirq.Sched)
+	add("hrtimer", softirq.HRTimer)
+	add("rcu", softirq.RCU)
+	return samples
 }
 
 func init() {
-	sources.Sources["procstat"] = NewEmitter
+	sources.Sources["procstat"] = collector.Factory(NewCollector)
 }
@@ -0,0 +1,17 @@
+package psi
+
+import (
+	"github.com/squizzling/stats/internal/args"
+)
+
+type PSIOpts struct {
+	CgroupRoot    string   `long:"psi.cgroup-root"    default:"/sys/fs/cgroup" description:"mount point of the cgroup v2 hierarchy"`
+	IncludeCgroup []string `long:"psi.include-cgroup"                          description:"cgroups to emit pressure for, relative to psi.cgroup-root, none if not set"`
+	ExcludeCgroup []string `long:"psi.exclude-cgroup"                          description:"cgroups to not emit pressure for"`
+}
+
+func (opts *PSIOpts) Validate() []string {
+	opts.IncludeCgroup = args.Flatten(opts.IncludeCgroup)
+	opts.ExcludeCgroup = args.Flatten(opts.ExcludeCgroup)
+	return nil
+}
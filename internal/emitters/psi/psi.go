@@ -0,0 +1,137 @@
+package psi
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"syscall"
+	"time"
+
+	"github.com/squizzling/glob/pkg/glob"
+	"go.uber.org/zap"
+
+	"github.com/squizzling/stats/pkg/collector"
+	"github.com/squizzling/stats/pkg/emitter"
+	"github.com/squizzling/stats/pkg/procfs"
+	"github.com/squizzling/stats/pkg/sources"
+)
+
+type PSICollector struct {
+	logger         *zap.Logger
+	cgroupRoot     string
+	cgroupPatterns glob.Matcher
+	walkCgroups    bool
+
+	// unavailable are the system-wide resources which the kernel doesn't
+	// provide pressure for, so they aren't retried.
+	unavailable map[string]struct{}
+}
+
+func NewCollector(logger *zap.Logger, opt emitter.OptProvider) collector.Collector {
+	opts := opt.Get("psi").(*PSIOpts)
+	return &PSICollector{
+		logger:         logger,
+		cgroupRoot:     opts.CgroupRoot,
+		cgroupPatterns: glob.NewACL(opts.IncludeCgroup, opts.ExcludeCgroup, false),
+		walkCgroups:    len(opts.IncludeCgroup) > 0,
+		unavailable:    make(map[string]struct{}),
+	}
+}
+
+// isUnavailable reports whether err means there is no pressure information,
+// rather than a failure to read it.  The files don't exist before 4.20 or
+// without CONFIG_PSI, and reading them fails with EOPNOTSUPP if PSI is
+// disabled with psi=0.
+func isUnavailable(err error) bool {
+	return os.IsNotExist(err) || errors.Is(err, syscall.EOPNOTSUPP)
+}
+
+func (pc *PSICollector) Collect(ctx context.Context, tick time.Time) ([]collector.Sample, error) {
+	var samples []collector.Sample
+	for _, resource := range procfs.PressureResources {
+		if _, ok := pc.unavailable[resource]; ok {
+			continue
+		}
+		p, err := procfs.ReadPressure(path.Join(procfs.PressurePath, resource))
+		if err != nil {
+			if isUnavailable(err) {
+				pc.logger.Info("pressure not available", zap.String("resource", resource), zap.Error(err))
+				pc.unavailable[resource] = struct{}{}
+			} else {
+				pc.logger.Warn("failed to read pressure", zap.String("resource", resource), zap.Error(err))
+			}
+			continue
+		}
+		samples = collectPressure(samples, resource, p)
+	}
+
+	if pc.walkCgroups {
+		var err error
+		if samples, err = pc.collectCgroups(ctx, samples); err != nil {
+			return samples, fmt.Errorf("failed to walk cgroups: %w", err)
+		}
+	}
+	return samples, nil
+}
+
+// collectCgroups adds the pressure for every selected cgroup under the root.
+// Files which don't exist are skipped quietly, as not every controller is
+// enabled in every cgroup.
+func (pc *PSICollector) collectCgroups(ctx context.Context, samples []collector.Sample) ([]collector.Sample, error) {
+	err := filepath.Walk(pc.cgroupRoot, func(p string, info os.FileInfo, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) {
+				// The cgroup was removed during the walk.
+				return nil
+			}
+			return err
+		}
+		if !info.IsDir() {
+			return nil
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		cgroup, err := filepath.Rel(pc.cgroupRoot, p)
+		if err != nil || cgroup == "." || !pc.cgroupPatterns.Match(cgroup) {
+			return nil
+		}
+		for _, resource := range procfs.PressureResources {
+			pressure, err := procfs.ReadPressure(path.Join(p, resource+".pressure"))
+			if err != nil {
+				if !isUnavailable(err) {
+					pc.logger.Warn("failed to read pressure", zap.String("cgroup", cgroup), zap.String("resource", resource), zap.Error(err))
+				}
+				continue
+			}
+			samples = collectPressure(samples, resource, pressure, "cgroup", cgroup)
+		}
+		return nil
+	})
+	return samples, err
+}
+
+func collectPressure(samples []collector.Sample, resource string, p *procfs.Pressure, tags ...string) []collector.Sample {
+	add := func(kind string, stall *procfs.PressureStall) {
+		if stall == nil {
+			return
+		}
+		prefix := fmt.Sprintf("psi.%s.%s.", resource, kind)
+		samples = append(samples,
+			collector.Gauge(prefix+"avg10", collector.UnitPercent, stall.Avg10, tags...),
+			collector.Gauge(prefix+"avg60", collector.UnitPercent, stall.Avg60, tags...),
+			collector.Gauge(prefix+"avg300", collector.UnitPercent, stall.Avg300, tags...),
+			collector.Cumulative(prefix+"total", collector.UnitMicroseconds, float64(stall.Total), tags...),
+		)
+	}
+	add("some", p.Some)
+	add("full", p.Full)
+	return samples
+}
+
+func init() {
+	sources.Sources["psi"] = collector.Factory(NewCollector)
+}
@@ -0,0 +1,16 @@
+package smart
+
+import (
+	"time"
+)
+
+type SmartOpts struct {
+	Timeout time.Duration `long:"smart.timeout" default:"10s" description:"time after which each smartctl command is killed, and counted as a failure"`
+}
+
+func (opts *SmartOpts) Validate() []string {
+	if opts.Timeout <= 0 {
+		return []string{"smart.timeout must be positive"}
+	}
+	return nil
+}
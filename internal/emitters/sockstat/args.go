@@ -0,0 +1,29 @@
+package sockstat
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/squizzling/stats/internal/args"
+)
+
+type SockStatOpts struct {
+	Port []string `long:"sockstat.port" description:"local TCP port to break down connection states and accept queue depth for, may be repeated"`
+
+	ports map[uint16]struct{}
+}
+
+func (opts *SockStatOpts) Validate() []string {
+	var errs []string
+	opts.Port = args.Flatten(opts.Port)
+	opts.ports = make(map[uint16]struct{})
+	for _, p := range opts.Port {
+		port, err := strconv.ParseUint(p, 10, 16)
+		if err != nil || port == 0 {
+			errs = append(errs, fmt.Sprintf("sockstat.port: invalid port %s", p))
+			continue
+		}
+		opts.ports[uint16(port)] = struct{}{}
+	}
+	return errs
+}
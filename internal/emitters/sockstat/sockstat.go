@@ -0,0 +1,94 @@
+package sockstat
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/squizzling/stats/pkg/collector"
+	"github.com/squizzling/stats/pkg/emitter"
+	"github.com/squizzling/stats/pkg/procfs"
+	"github.com/squizzling/stats/pkg/sources"
+)
+
+const (
+	tcpPath  = "/proc/net/tcp"
+	tcp6Path = "/proc/net/tcp6"
+)
+
+type SockStatCollector struct {
+	logger *zap.Logger
+	ports  map[uint16]struct{}
+}
+
+func NewCollector(logger *zap.Logger, opt emitter.OptProvider) collector.Collector {
+	opts := opt.Get("sockstat").(*SockStatOpts)
+	return &SockStatCollector{
+		logger: logger,
+		ports:  opts.ports,
+	}
+}
+
+func (ssc *SockStatCollector) Collect(ctx context.Context, tick time.Time) ([]collector.Sample, error) {
+	var samples []collector.Sample
+	for _, filename := range []string{procfs.SockStatPath, procfs.SockStat6Path} {
+		ss, err := procfs.ReadSockStat(filename)
+		if err != nil {
+			if os.IsNotExist(err) {
+				// IPv6 is disabled.
+				continue
+			}
+			return nil, fmt.Errorf("failed to read sockstat: %w", err)
+		}
+		for protocol, values := range ss {
+			for name, value := range values {
+				samples = append(samples, collector.Gauge(
+					fmt.Sprintf("sockstat.%s.%s", strings.ToLower(protocol), name),
+					collector.UnitNone,
+					float64(value),
+				))
+			}
+		}
+	}
+
+	ts := newTCPSummary()
+	for _, filename := range []string{tcpPath, tcp6Path} {
+		if err := ts.add(filename, ssc.ports); err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return samples, fmt.Errorf("failed to read tcp sockets: %w", err)
+		}
+		if ctx.Err() != nil {
+			return samples, ctx.Err()
+		}
+	}
+	samples = collectStates(samples, "sockstat.tcp.connections", &ts.states)
+	for port, counts := range ts.portStates {
+		samples = collectStates(samples, "sockstat.tcp.port.connections", counts, "port", strconv.Itoa(int(port)))
+	}
+	for port, depth := range ts.acceptQueue {
+		samples = append(samples, collector.Gauge("sockstat.tcp.port.accept_queue", collector.UnitNone, float64(depth), "port", strconv.Itoa(int(port))))
+	}
+	return samples, nil
+}
+
+func collectStates(samples []collector.Sample, name string, counts *tcpStateCounts, tags ...string) []collector.Sample {
+	for state, count := range counts {
+		if state == 0 {
+			continue
+		}
+		stateTags := append([]string{"state", tcpStates[state]}, tags...)
+		samples = append(samples, collector.Gauge(name, collector.UnitNone, float64(count), stateTags...))
+	}
+	return samples
+}
+
+func init() {
+	sources.Sources["sockstat"] = collector.Factory(NewCollector)
+}
@@ -0,0 +1,153 @@
+package sockstat
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/squizzling/stats/internal/iio"
+)
+
+// tcpStates are the names of the TCP states, indexed by their number in
+// /proc/net/tcp.
+var tcpStates = [...]string{
+	1:  "established",
+	2:  "syn_sent",
+	3:  "syn_recv",
+	4:  "fin_wait1",
+	5:  "fin_wait2",
+	6:  "time_wait",
+	7:  "close",
+	8:  "close_wait",
+	9:  "last_ack",
+	10: "listen",
+	11: "closing",
+	12: "new_syn_recv",
+}
+
+const tcpListen = 10
+
+type tcpStateCounts [len(tcpStates)]uint64
+
+// tcpSummary is the number of sockets in each state, and for the selected
+// local ports, the number in each state and the depth of the accept queue
+// of listeners.
+type tcpSummary struct {
+	states      tcpStateCounts
+	portStates  map[uint16]*tcpStateCounts
+	acceptQueue map[uint16]uint64
+}
+
+func newTCPSummary() *tcpSummary {
+	return &tcpSummary{
+		portStates:  make(map[uint16]*tcpStateCounts),
+		acceptQueue: make(map[uint16]uint64),
+	}
+}
+
+// add reads /proc/net/tcp or tcp6 into the summary.  These can have hundreds
+// of thousands of lines on a busy host, so they are streamed and parsed
+// without allocating per line.
+func (ts *tcpSummary) add(filename string, ports map[uint16]struct{}) error {
+	f, err := os.Open(filename)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = f.Close()
+	}()
+
+	r := bufio.NewReaderSize(f, 64*1024)
+	header := true
+	for {
+		line, err := r.ReadSlice('\n')
+		if err == io.EOF && len(line) == 0 {
+			return nil
+		} else if err != nil && err != io.EOF {
+			return err
+		}
+		if header {
+			header = false
+			continue
+		}
+		if err := ts.addLine(line, ports); err != nil {
+			return fmt.Errorf("%s: %v", filename, err)
+		}
+	}
+}
+
+// addLine adds a line of the form:
+//
+//	sl  local_address rem_address   st tx_queue rx_queue ...
+//	0: 00000000:07E8 00000000:0000 0A 00000000:00000000 ...
+//
+// For a listener, rx_queue is the number of connections waiting to be
+// accepted.
+func (ts *tcpSummary) addLine(line []byte, ports map[uint16]struct{}) error {
+	c := iio.NewChunker(bytes.TrimRight(line, "\n"))
+	c.NextChunk() // sl
+	local := c.NextChunk()
+	c.NextChunk() // rem_address
+	st := c.NextChunk()
+	queues := c.NextChunk()
+	if err := c.Err(); err != nil {
+		return fmt.Errorf("malformed line %q: %v", line, err)
+	}
+
+	state, ok := parseHex(st)
+	if !ok || state == 0 || state >= uint64(len(tcpStates)) {
+		return fmt.Errorf("invalid state %q", st)
+	}
+	ts.states[state]++
+
+	if len(ports) == 0 {
+		return nil
+	}
+	colon := bytes.LastIndexByte(local, ':')
+	port, ok := parseHex(local[colon+1:])
+	if colon == -1 || !ok {
+		return fmt.Errorf("invalid local address %q", local)
+	}
+	if _, ok := ports[uint16(port)]; !ok {
+		return nil
+	}
+	counts := ts.portStates[uint16(port)]
+	if counts == nil {
+		counts = &tcpStateCounts{}
+		ts.portStates[uint16(port)] = counts
+	}
+	counts[state]++
+
+	if state == tcpListen {
+		colon = bytes.IndexByte(queues, ':')
+		rxQueue, ok := parseHex(queues[colon+1:])
+		if colon == -1 || !ok {
+			return fmt.Errorf("invalid queues %q", queues)
+		}
+		ts.acceptQueue[uint16(port)] += rxQueue
+	}
+	return nil
+}
+
+// parseHex parses an unprefixed hexadecimal number.
+func parseHex(b []byte) (uint64, bool) {
+	if len(b) == 0 || len(b) > 16 {
+		return 0, false
+	}
+	var v uint64
+	for _, ch := range b {
+		switch {
+		case ch >= '0' && ch <= '9':
+			v = v<<4 | uint64(ch-'0')
+		case ch >= 'A' && ch <= 'F':
+			v = v<<4 | uint64(ch-'A'+10)
+		case ch >= 'a' && ch <= 'f':
+			v = v<<4 | uint64(ch-'a'+10)
+		default:
+			return 0, false
+		}
+	}
+	return v, true
+}
@@ -0,0 +1,219 @@
+package statsdlistener
+
+import (
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/squizzling/stats/internal/textformat"
+	"github.com/squizzling/stats/pkg/statser"
+)
+
+// aggregator accumulates samples between flushes.  Counters are summed, gauges
+// keep their last value, sets count their unique members, and timings,
+// histograms and distributions are summarised.  Gauge values are remembered
+// across flushes so relative gauges have something to adjust, but only gauges
+// which were updated are emitted, and gauges which aren't updated within
+// gaugeExpiry flushes are forgotten.
+type aggregator struct {
+	lock        sync.Mutex
+	percentiles []float64
+	gaugeExpiry int
+
+	counters      map[string]*counter
+	gauges        map[string]*gauge
+	sets          map[string]*set
+	distributions map[string]*distribution
+}
+
+type series struct {
+	name string
+	tags []string
+}
+
+type counter struct {
+	series
+	value float64
+}
+
+type gauge struct {
+	series
+	value   float64
+	updated bool
+	idle    int // flushes since the last update
+}
+
+type set struct {
+	series
+	members map[string]struct{}
+}
+
+type distribution struct {
+	series
+	values []float64
+	count  float64
+}
+
+func newAggregator(percentiles []float64, gaugeExpiry int) *aggregator {
+	a := &aggregator{
+		percentiles: percentiles,
+		gaugeExpiry: gaugeExpiry,
+		gauges:      make(map[string]*gauge),
+	}
+	a.reset()
+	return a
+}
+
+func (a *aggregator) reset() {
+	a.counters = make(map[string]*counter)
+	a.sets = make(map[string]*set)
+	a.distributions = make(map[string]*distribution)
+}
+
+// tagPairs sorts a list of tag names and values by name, then value.
+type tagPairs []string
+
+func (tp tagPairs) Len() int {
+	return len(tp) / 2
+}
+
+func (tp tagPairs) Less(i, j int) bool {
+	if tp[2*i] != tp[2*j] {
+		return tp[2*i] < tp[2*j]
+	}
+	return tp[2*i+1] < tp[2*j+1]
+}
+
+func (tp tagPairs) Swap(i, j int) {
+	tp[2*i], tp[2*j] = tp[2*j], tp[2*i]
+	tp[2*i+1], tp[2*j+1] = tp[2*j+1], tp[2*i+1]
+}
+
+// sortTags returns a copy of the tag pairs in tags, sorted by name and value,
+// so the order they were sent in doesn't make a different series.
+func sortTags(tags []string) []string {
+	sorted := append(tagPairs{}, tags[:len(tags)&^1]...)
+	sort.Sort(sorted)
+	return sorted
+}
+
+func seriesKey(name string, tags []string) string {
+	return name + "|" + strings.Join(tags, "||")
+}
+
+func (a *aggregator) add(samples []textformat.Sample) {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+
+	for i := range samples {
+		s := &samples[i]
+		tags := sortTags(s.Tags)
+		key := seriesKey(s.Name, tags)
+		switch s.Kind {
+		case textformat.KindCounter:
+			c, ok := a.counters[key]
+			if !ok {
+				c = &counter{series: series{s.Name, tags}}
+				a.counters[key] = c
+			}
+			c.value += s.Value / s.SampleRate
+		case textformat.KindGauge:
+			g, ok := a.gauges[key]
+			if !ok {
+				g = &gauge{series: series{s.Name, tags}}
+				a.gauges[key] = g
+			}
+			if s.Relative {
+				g.value += s.Value
+			} else {
+				g.value = s.Value
+			}
+			g.updated = true
+		case textformat.KindSet:
+			st, ok := a.sets[key]
+			if !ok {
+				st = &set{series: series{s.Name, tags}, members: make(map[string]struct{})}
+				a.sets[key] = st
+			}
+			st.members[s.SetValue] = struct{}{}
+		default:
+			d, ok := a.distributions[key]
+			if !ok {
+				d = &distribution{series: series{s.Name, tags}}
+				a.distributions[key] = d
+			}
+			d.values = append(d.values, s.Value)
+			d.count += 1 / s.SampleRate
+		}
+	}
+}
+
+// percentileName formats a percentile the way the Datadog agent does, so 95
+// becomes 95percentile and 99.9 becomes 99_9percentile.
+func percentileName(p float64) string {
+	return strings.Replace(strconv.FormatFloat(p, 'f', -1, 64), ".", "_", 1) + "percentile"
+}
+
+// percentile returns the nearest rank percentile of sorted values.
+func percentile(sorted []float64, p float64) float64 {
+	rank := int(math.Ceil(p/100*float64(len(sorted)))) - 1
+	if rank < 0 {
+		rank = 0
+	}
+	return sorted[rank]
+}
+
+func (d *distribution) flush(statsPool statser.Pool, percentiles []float64) {
+	sort.Float64s(d.values)
+	sum := 0.0
+	for _, v := range d.values {
+		sum += v
+	}
+
+	c := statsPool.Host(d.tags...)
+	c.Count(d.name+".count", d.count)
+	c.Gauge(d.name+".min", d.values[0])
+	c.Gauge(d.name+".max", d.values[len(d.values)-1])
+	c.Gauge(d.name+".avg", sum/float64(len(d.values)))
+	c.Gauge(d.name+".median", percentile(d.values, 50))
+	for _, p := range percentiles {
+		c.Gauge(d.name+"."+percentileName(p), percentile(d.values, p))
+	}
+}
+
+// flush emits everything accumulated since the last flush, and returns the
+// number of series emitted, and the number of gauges expired.
+func (a *aggregator) flush(statsPool statser.Pool) (int, int) {
+	a.lock.Lock()
+	counters, sets, distributions := a.counters, a.sets, a.distributions
+	a.reset()
+	var gauges []gauge
+	expired := 0
+	for key, g := range a.gauges {
+		if g.updated {
+			gauges = append(gauges, *g)
+			g.updated = false
+			g.idle = 0
+		} else if g.idle++; g.idle >= a.gaugeExpiry {
+			delete(a.gauges, key)
+			expired++
+		}
+	}
+	a.lock.Unlock()
+
+	for _, c := range counters {
+		statsPool.Host(c.tags...).Count(c.name, c.value)
+	}
+	for _, g := range gauges {
+		statsPool.Host(g.tags...).Gauge(g.name, g.value)
+	}
+	for _, st := range sets {
+		statsPool.Host(st.tags...).Gauge(st.name, len(st.members))
+	}
+	for _, d := range distributions {
+		d.flush(statsPool, a.percentiles)
+	}
+	return len(counters) + len(gauges) + len(sets) + len(distributions), expired
+}
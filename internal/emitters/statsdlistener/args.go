@@ -0,0 +1,37 @@
+package statsdlistener
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/squizzling/stats/internal/args"
+)
+
+type StatsdListenerOpts struct {
+	UDPAddress  string   `long:"statsd.udp-address"               description:"address to receive statsd on over UDP, eg 127.0.0.1:8125"`
+	UnixSocket  string   `long:"statsd.unix-socket"               description:"path of a unix datagram socket to receive statsd on"`
+	Percentiles []string `long:"statsd.percentile"                description:"percentiles to emit for timings, histograms and distributions (default 95)"`
+	GaugeExpiry int      `long:"statsd.gauge-expiry" default:"60" description:"flushes without an update after which a gauge is forgotten, and relative updates start again from 0"`
+
+	percentiles []float64
+}
+
+func (opts *StatsdListenerOpts) Validate() []string {
+	var errs []string
+	opts.Percentiles = args.Flatten(opts.Percentiles)
+	if len(opts.Percentiles) == 0 {
+		opts.Percentiles = []string{"95"}
+	}
+	if opts.GaugeExpiry < 1 {
+		errs = append(errs, "statsd.gauge-expiry must be at least 1")
+	}
+	for _, s := range opts.Percentiles {
+		p, err := strconv.ParseFloat(s, 64)
+		if err != nil || p <= 0 || p >= 100 {
+			errs = append(errs, fmt.Sprintf("statsd.percentile %s must be between 0 and 100", s))
+			continue
+		}
+		opts.percentiles = append(opts.percentiles, p)
+	}
+	return errs
+}
@@ -0,0 +1,116 @@
+package statsdlistener
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"runtime/debug"
+	"sync/atomic"
+
+	"go.uber.org/zap"
+
+	"github.com/squizzling/stats/internal/textformat"
+	"github.com/squizzling/stats/pkg/emitter"
+	"github.com/squizzling/stats/pkg/sources"
+	"github.com/squizzling/stats/pkg/statser"
+)
+
+// StatsdListenerEmitter receives DogStatsD from local applications, and
+// relays it through the pool aggregated over each tick.  Since Emit is
+// called on the aligned ticker, each flush covers exactly one interval.
+type StatsdListenerEmitter struct {
+	logger     *zap.Logger
+	statsPool  statser.Pool
+	aggregator *aggregator
+
+	packets     int64
+	samples     int64
+	parseErrors int64
+}
+
+func NewEmitter(logger *zap.Logger, statsPool statser.Pool, opt emitter.OptProvider) emitter.Emitter {
+	opts := opt.Get("statsd").(*StatsdListenerOpts)
+
+	sle := &StatsdListenerEmitter{
+		logger:     logger,
+		statsPool:  statsPool,
+		aggregator: newAggregator(opts.percentiles, opts.GaugeExpiry),
+	}
+
+	if opts.UDPAddress == "" && opts.UnixSocket == "" {
+		logger.Debug("no listeners configured")
+		return sle
+	}
+
+	if opts.UDPAddress != "" {
+		conn, err := net.ListenPacket("udp", opts.UDPAddress)
+		if err != nil {
+			logger.Error("failed to listen", zap.String("address", opts.UDPAddress), zap.Error(err))
+			return nil
+		}
+		go sle.receive(conn)
+	}
+
+	if opts.UnixSocket != "" {
+		// A socket left behind by a previous run would prevent binding.
+		if err := os.Remove(opts.UnixSocket); err != nil && !os.IsNotExist(err) {
+			logger.Error("failed to remove socket", zap.String("path", opts.UnixSocket), zap.Error(err))
+			return nil
+		}
+		conn, err := net.ListenPacket("unixgram", opts.UnixSocket)
+		if err != nil {
+			logger.Error("failed to listen", zap.String("path", opts.UnixSocket), zap.Error(err))
+			return nil
+		}
+		go sle.receive(conn)
+	}
+
+	return sle
+}
+
+func (sle *StatsdListenerEmitter) receive(conn net.PacketConn) {
+	buf := make([]byte, 65536)
+	for {
+		n, _, err := conn.ReadFrom(buf)
+		if err != nil {
+			sle.logger.Error("failed to read", zap.String("address", conn.LocalAddr().String()), zap.Error(err))
+			return
+		}
+		sle.handle(buf[:n])
+	}
+}
+
+// handle parses and aggregates a packet.  The receiver isn't covered by the
+// recovery around EmitTick, so a panic, such as from a malformed packet, is
+// recovered here, and only loses the packet.
+func (sle *StatsdListenerEmitter) handle(packet []byte) {
+	defer func() {
+		if r := recover(); r != nil {
+			sle.logger.Error("packet handling panicked", zap.String("panic", fmt.Sprint(r)), zap.ByteString("stack", debug.Stack()))
+		}
+	}()
+	samples, errs := textformat.ParseStatsd(packet)
+	for _, err := range errs {
+		sle.logger.Debug("failed to parse", zap.Error(err))
+	}
+	sle.aggregator.add(samples)
+
+	atomic.AddInt64(&sle.packets, 1)
+	atomic.AddInt64(&sle.samples, int64(len(samples)))
+	atomic.AddInt64(&sle.parseErrors, int64(len(errs)))
+}
+
+func (sle *StatsdListenerEmitter) Emit() {
+	series, expired := sle.aggregator.flush(sle.statsPool)
+
+	c := sle.statsPool.Host()
+	c.Count("statsd.packets", atomic.SwapInt64(&sle.packets, 0))
+	c.Count("statsd.samples", atomic.SwapInt64(&sle.samples, 0))
+	c.Count("statsd.parse_errors", atomic.SwapInt64(&sle.parseErrors, 0))
+	c.Gauge("statsd.series", series)
+	c.Count("statsd.gauges_expired", expired)
+}
+
+func init() {
+	sources.Sources["statsd"] = NewEmitter
+}
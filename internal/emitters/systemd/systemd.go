@@ -4,6 +4,7 @@ import (
 	"github.com/godbus/dbus"
 	"go.uber.org/zap"
 
+	"github.com/squizzling/stats/internal/backoff"
 	"github.com/squizzling/stats/pkg/emitter"
 	"github.com/squizzling/stats/pkg/sources"
 	"github.com/squizzling/stats/pkg/statser"
@@ -11,8 +12,10 @@ import (
 
 type SystemdEmitter struct {
 	logger      *zap.Logger
+	statsPool   statser.Pool
 	statsClient statser.Statser
 	obj         dbus.BusObject
+	breaker     *backoff.Breaker
 }
 
 const (
@@ -30,8 +33,10 @@ func NewEmitter(logger *zap.Logger, statsPool statser.Pool, opt emitter.OptProvi
 
 	sde := &SystemdEmitter{
 		logger:      logger,
+		statsPool:   statsPool,
 		statsClient: statsPool.Host(),
 		obj:         b.Object(destSystemd, pathSystemd),
+		breaker:     backoff.NewBreaker(logger, "systemd", opt.Get("backoff").(*backoff.BackoffOpts)),
 	}
 
 	v := sde.failedUnits()
@@ -45,8 +50,7 @@ func NewEmitter(logger *zap.Logger, statsPool statser.Pool, opt emitter.OptProvi
 
 func (sde *SystemdEmitter) failedUnits() int64 {
 	v, err := sde.obj.GetProperty(propFailedUnits)
-	if err != nil {
-		sde.logger.Error("failed to read NFailedUnits", zap.Error(err))
+	if sde.breaker.Check("read-failed-units", err) {
 		return -1
 	}
 	if u, ok := v.Value().(uint32); ok {
@@ -56,7 +60,14 @@ func (sde *SystemdEmitter) failedUnits() int64 {
 }
 
 func (sde *SystemdEmitter) Emit() {
-	sde.statsClient.Gauge("systemd.failed_units", sde.failedUnits())
+	defer sde.breaker.Report(sde.statsPool)
+	if !sde.breaker.Allow() {
+		return
+	}
+	if v := sde.failedUnits(); v >= 0 {
+		sde.statsClient.Gauge("systemd.failed_units", v)
+		sde.breaker.Success()
+	}
 }
 
 func init() {
@@ -0,0 +1,17 @@
+package textfile
+
+import (
+	"time"
+)
+
+type TextFileOpts struct {
+	Directory string        `long:"textfile.directory" default:"/var/lib/node_exporter/textfile_collector" description:"directory containing Prometheus .prom files"`
+	MaxAge    time.Duration `long:"textfile.max-age"   default:"0"                                         description:"ignore files not modified within this duration, 0 to never ignore"`
+}
+
+func (opts *TextFileOpts) Validate() []string {
+	if opts.MaxAge < 0 {
+		return []string{"textfile.max-age must not be negative"}
+	}
+	return nil
+}
@@ -0,0 +1,146 @@
+package textfile
+
+import (
+	"io/ioutil"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/squizzling/stats/internal/iio"
+	"github.com/squizzling/stats/internal/textformat"
+	"github.com/squizzling/stats/pkg/collector"
+	"github.com/squizzling/stats/pkg/emitter"
+	"github.com/squizzling/stats/pkg/sources"
+	"github.com/squizzling/stats/pkg/statser"
+)
+
+// TextFileEmitter emits the samples from the Prometheus .prom files in a
+// directory, in the manner of the node_exporter textfile collector.  Files are
+// only parsed again when their modification time or size changes.
+type TextFileEmitter struct {
+	logger    *zap.Logger
+	statsPool statser.Pool
+	directory string
+	maxAge    time.Duration
+
+	files map[string]*textFile
+}
+
+type textFile struct {
+	modTime     time.Time
+	size        int64
+	samples     []textformat.Sample
+	parseErrors int
+}
+
+func NewEmitter(logger *zap.Logger, statsPool statser.Pool, opt emitter.OptProvider) emitter.Emitter {
+	opts := opt.Get("textfile").(*TextFileOpts)
+	return &TextFileEmitter{
+		logger:    logger,
+		statsPool: statsPool,
+		directory: opts.Directory,
+		maxAge:    opts.MaxAge,
+		files:     make(map[string]*textFile),
+	}
+}
+
+func (tfe *TextFileEmitter) listFiles() []os.FileInfo {
+	entries, err := ioutil.ReadDir(tfe.directory)
+	if err != nil {
+		if os.IsNotExist(err) {
+			tfe.logger.Debug("directory does not exist", zap.String("directory", tfe.directory))
+		} else {
+			tfe.logger.Warn("failed to read directory", zap.String("directory", tfe.directory), zap.Error(err))
+		}
+		return nil
+	}
+
+	var files []os.FileInfo
+	for _, entry := range entries {
+		if entry.Mode().IsRegular() && strings.HasSuffix(entry.Name(), ".prom") {
+			files = append(files, entry)
+		}
+	}
+	sort.Slice(files, func(i, j int) bool {
+		return files[i].Name() < files[j].Name()
+	})
+	return files
+}
+
+func (tfe *TextFileEmitter) loadFile(fi os.FileInfo) *textFile {
+	if tf, ok := tfe.files[fi.Name()]; ok && tf.modTime.Equal(fi.ModTime()) && tf.size == fi.Size() {
+		return tf
+	}
+
+	data := iio.ReadEntireFile(tfe.logger, filepath.Join(tfe.directory, fi.Name()))
+	if data == nil {
+		return nil
+	}
+
+	samples, errs := textformat.ParsePrometheus(data)
+	for _, err := range errs {
+		tfe.logger.Warn("failed to parse", zap.String("file", fi.Name()), zap.Error(err))
+	}
+	tf := &textFile{
+		modTime:     fi.ModTime(),
+		size:        fi.Size(),
+		samples:     samples,
+		parseErrors: len(errs),
+	}
+	tfe.files[fi.Name()] = tf
+	return tf
+}
+
+func (tfe *TextFileEmitter) Emit() {
+	now := time.Now()
+	seen := make(map[string]struct{})
+
+	for _, fi := range tfe.listFiles() {
+		seen[fi.Name()] = struct{}{}
+		tf := tfe.loadFile(fi)
+		if tf == nil {
+			tfe.statsPool.Host("file", fi.Name()).Gauge("textfile.read_error", 1)
+			continue
+		}
+
+		age := now.Sub(tf.modTime)
+		c := tfe.statsPool.Host("file", fi.Name())
+		c.Gauge("textfile.read_error", 0)
+		c.Gauge("textfile.mtime", tf.modTime.Unix())
+		c.Gauge("textfile.age", age.Seconds())
+		c.Gauge("textfile.parse_errors", tf.parseErrors)
+		c.Gauge("textfile.samples", len(tf.samples))
+
+		if tfe.maxAge != 0 && age > tfe.maxAge {
+			tfe.logger.Debug("skipping stale file", zap.String("file", fi.Name()), zap.Duration("age", age))
+			continue
+		}
+
+		for _, sample := range tf.samples {
+			if math.IsNaN(sample.Value) || math.IsInf(sample.Value, 0) {
+				continue
+			}
+			// Counters are emitted as gauges, the same as every other cumulative value.
+			c := tfe.statsPool.Host(sample.Tags...)
+			if sample.Kind == textformat.KindCounter {
+				c = collector.Describe(c, collector.KindCumulative, collector.UnitNone)
+			}
+			c.Gauge(sample.Name, sample.Value)
+		}
+	}
+
+	for name := range tfe.files {
+		if _, ok := seen[name]; !ok {
+			delete(tfe.files, name)
+		}
+	}
+}
+
+func init() {
+	sources.Sources["textfile"] = NewEmitter
+}
@@ -0,0 +1,16 @@
+package vmstat
+
+import (
+	"github.com/squizzling/stats/internal/args"
+)
+
+type VmStatOpts struct {
+	IncludeKey []string `long:"vmstat.include-key" description:"keys from /proc/vmstat to emit, all if not set"`
+	ExcludeKey []string `long:"vmstat.exclude-key" description:"keys from /proc/vmstat to not emit"`
+}
+
+func (opts *VmStatOpts) Validate() []string {
+	opts.IncludeKey = args.Flatten(opts.IncludeKey)
+	opts.ExcludeKey = args.Flatten(opts.ExcludeKey)
+	return nil
+}
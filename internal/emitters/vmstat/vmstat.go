@@ -0,0 +1,76 @@
+package vmstat
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/squizzling/glob/pkg/glob"
+	"go.uber.org/zap"
+
+	"github.com/squizzling/stats/pkg/collector"
+	"github.com/squizzling/stats/pkg/emitter"
+	"github.com/squizzling/stats/pkg/procfs"
+	"github.com/squizzling/stats/pkg/sources"
+)
+
+type VmStatCollector struct {
+	logger      *zap.Logger
+	keyPatterns glob.Matcher
+}
+
+func NewCollector(logger *zap.Logger, opt emitter.OptProvider) collector.Collector {
+	opts := opt.Get("vmstat").(*VmStatOpts)
+	return &VmStatCollector{
+		logger:      logger,
+		keyPatterns: glob.NewACL(opts.IncludeKey, opts.ExcludeKey, len(opts.IncludeKey) == 0),
+	}
+}
+
+// Most keys count events since boot, but the nr_ keys are mostly the current
+// number of pages or objects, and are emitted as gauges.  cumulativeNr are
+// the nr_ keys which count events.
+var cumulativeNr = map[string]struct{}{
+	"nr_dirtied":                   {},
+	"nr_written":                   {},
+	"nr_vmscan_write":              {},
+	"nr_vmscan_immediate_reclaim":  {},
+	"nr_foll_pin_acquired":         {},
+	"nr_foll_pin_released":         {},
+	"nr_tlb_remote_flush":          {},
+	"nr_tlb_remote_flush_received": {},
+	"nr_tlb_local_flush_all":       {},
+	"nr_tlb_local_flush_one":       {},
+}
+
+func isCumulative(key string) bool {
+	if !strings.HasPrefix(key, "nr_") {
+		return true
+	}
+	_, ok := cumulativeNr[key]
+	return ok
+}
+
+func (vsc *VmStatCollector) Collect(ctx context.Context, tick time.Time) ([]collector.Sample, error) {
+	vs, err := procfs.ReadVmStat(procfs.VmStatPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read vmstat: %w", err)
+	}
+	var samples []collector.Sample
+	for key, value := range vs.Values {
+		if !vsc.keyPatterns.Match(key) {
+			continue
+		}
+		if isCumulative(key) {
+			samples = append(samples, collector.Cumulative("vmstat."+key, collector.UnitNone, float64(value)))
+		} else {
+			samples = append(samples, collector.Gauge("vmstat."+key, collector.UnitNone, float64(value)))
+		}
+	}
+	return samples, nil
+}
+
+func init() {
+	sources.Sources["vmstat"] = collector.Factory(NewCollector)
+}
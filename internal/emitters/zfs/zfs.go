@@ -6,8 +6,8 @@ import (
 
 	"go.uber.org/zap"
 
-	"github.com/squizzling/stats/internal/emitters/zfs/kstat"
 	"github.com/squizzling/stats/pkg/emitter"
+	"github.com/squizzling/stats/pkg/kstat"
 	"github.com/squizzling/stats/pkg/sources"
 	"github.com/squizzling/stats/pkg/statser"
 )
@@ -41,11 +41,23 @@ func (e *ZFSEmitter) statPoolIo(poolName string) *kstat.Kstat {
 			uint_t          rcnt;           // count of elements in run state
 		} kstat_io_t;
 	*/
-	return kstat.LoadKstat(fmt.Sprintf("/proc/spl/kstat/zfs/%s/io", poolName), e.logger)
+	return e.readKstat(fmt.Sprintf("/proc/spl/kstat/zfs/%s/io", poolName))
 }
 
 func (e *ZFSEmitter) statArc() *kstat.Kstat {
-	return kstat.LoadKstat("/proc/spl/kstat/zfs/arcstats", e.logger)
+	return e.readKstat("/proc/spl/kstat/zfs/arcstats")
+}
+
+func (e *ZFSEmitter) readKstat(file string) *kstat.Kstat {
+	kst, err := kstat.ReadKstat(file)
+	if err != nil {
+		e.logger.Warn("failed to read kstat", zap.Error(err))
+		return nil
+	}
+	for _, err := range kst.Skipped {
+		e.logger.Warn("skipped kstat value", zap.String("file", file), zap.Error(err))
+	}
+	return kst
 }
 
 func (e *ZFSEmitter) statPools() map[string]*kstat.Kstat {
@@ -65,10 +77,11 @@ func (e *ZFSEmitter) statPools() map[string]*kstat.Kstat {
 }
 
 func (e *ZFSEmitter) Emit() {
-	kst := e.statArc()
-	for k, v := range kst.UValues {
-		metricName := "zfs.arc." + k
-		e.statsPool.Host().Gauge(metricName, v)
+	if kst := e.statArc(); kst != nil {
+		for k, v := range kst.UValues {
+			metricName := "zfs.arc." + k
+			e.statsPool.Host().Gauge(metricName, v)
+		}
 	}
 
 	for poolName, kst := range e.statPools() {
@@ -0,0 +1,34 @@
+package history
+
+import (
+	"time"
+)
+
+type HistoryOpts struct {
+	Listen       string        `long:"history.listen"                           description:"address to serve the history query API on, history is disabled if not set"`
+	Retention    time.Duration `long:"history.retention"     default:"6h"       description:"how long samples are kept"`
+	Resolution   time.Duration `long:"history.resolution"    default:"10s"      description:"samples in the same period are kept as one point, the last for gauges and the sum for counts"`
+	MaxMemory    int64         `long:"history.max-memory"    default:"67108864" description:"approximate maximum memory used by history in bytes, new series are dropped once it is reached"`
+	File         string        `long:"history.file"                             description:"file to persist history to, so it survives restarts"`
+	SaveInterval time.Duration `long:"history.save-interval" default:"5m"       description:"interval between saves to history.file"`
+}
+
+func (opts *HistoryOpts) Validate() []string {
+	if opts.Listen == "" {
+		return nil
+	}
+	var errs []string
+	if opts.Resolution < time.Second {
+		errs = append(errs, "history.resolution must be at least 1s")
+	}
+	if opts.Retention < opts.Resolution {
+		errs = append(errs, "history.retention must be at least history.resolution")
+	}
+	if opts.MaxMemory <= 0 {
+		errs = append(errs, "history.max-memory must be positive")
+	}
+	if opts.File != "" && opts.SaveInterval <= 0 {
+		errs = append(errs, "history.save-interval must be positive")
+	}
+	return errs
+}
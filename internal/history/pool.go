@@ -0,0 +1,80 @@
+package history
+
+import (
+	"time"
+
+	"github.com/squizzling/stats/internal/istats"
+	"github.com/squizzling/stats/pkg/statser"
+)
+
+var _ = statser.Pool(&Pool{})
+var _ = statser.TimestampStatser(&historyStatser{})
+
+// Pool is a statser.Pool which records everything in a Store, and passes it
+// on to another pool.  The host tag isn't recorded, as the history is only
+// ever for this host.
+type Pool struct {
+	store *Store
+	pool  statser.Pool
+}
+
+func NewPool(store *Store, pool statser.Pool) *Pool {
+	return &Pool{
+		store: store,
+		pool:  pool,
+	}
+}
+
+func (p *Pool) Host(tags ...string) statser.Statser {
+	return &historyStatser{
+		store:   p.store,
+		tags:    tags,
+		statser: p.pool.Host(tags...),
+	}
+}
+
+func (p *Pool) Global(tags ...string) statser.Statser {
+	return &historyStatser{
+		store:   p.store,
+		tags:    tags,
+		statser: p.pool.Global(tags...),
+	}
+}
+
+type historyStatser struct {
+	store     *Store
+	tags      []string
+	statser   statser.Statser
+	timestamp time.Time
+}
+
+func (hs *historyStatser) WithTimestamp(t time.Time) statser.Statser {
+	return &historyStatser{
+		store:     hs.store,
+		tags:      hs.tags,
+		statser:   statser.At(hs.statser, t),
+		timestamp: t,
+	}
+}
+
+func (hs *historyStatser) record(metricName string, count bool, value interface{}) {
+	v, ok := istats.ToFloat64(value)
+	if !ok {
+		return
+	}
+	t := hs.timestamp
+	if t.IsZero() {
+		t = time.Now()
+	}
+	hs.store.Add(metricName, hs.tags, count, t, v)
+}
+
+func (hs *historyStatser) Gauge(metricName string, value interface{}) {
+	hs.record(metricName, false, value)
+	hs.statser.Gauge(metricName, value)
+}
+
+func (hs *historyStatser) Count(metricName string, value interface{}) {
+	hs.record(metricName, true, value)
+	hs.statser.Count(metricName, value)
+}
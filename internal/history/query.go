@@ -0,0 +1,215 @@
+package history
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/squizzling/glob/pkg/glob"
+)
+
+// Aggregation combines the points within a step.
+type Aggregation string
+
+const (
+	AggregationAvg  = Aggregation("avg")
+	AggregationMin  = Aggregation("min")
+	AggregationMax  = Aggregation("max")
+	AggregationSum  = Aggregation("sum")
+	AggregationLast = Aggregation("last")
+)
+
+func ParseAggregation(s string) (Aggregation, error) {
+	switch a := Aggregation(s); a {
+	case AggregationAvg, AggregationMin, AggregationMax, AggregationSum, AggregationLast:
+		return a, nil
+	default:
+		return "", fmt.Errorf("unknown aggregation %s, expected avg, min, max, sum, or last", s)
+	}
+}
+
+// TagFilter matches series with a tag whose value matches a glob.
+type TagFilter struct {
+	Key   string
+	Value glob.Matcher
+}
+
+type Query struct {
+	Metric glob.Matcher
+	Tags   []TagFilter
+
+	// Start and End are inclusive, in unix seconds.
+	Start int64
+	End   int64
+
+	// Step is the width in seconds of the buckets points are combined into,
+	// or 0 to return every point.
+	Step        int64
+	Aggregation Aggregation
+}
+
+type Point struct {
+	Timestamp int64
+	Value     float64
+}
+
+// MarshalJSON writes a point as [timestamp, value].
+func (p Point) MarshalJSON() ([]byte, error) {
+	return []byte("[" + strconv.FormatInt(p.Timestamp, 10) + "," + strconv.FormatFloat(p.Value, 'g', -1, 64) + "]"), nil
+}
+
+type Result struct {
+	Name   string            `json:"name"`
+	Tags   map[string]string `json:"tags"`
+	Points []Point           `json:"points,omitempty"`
+}
+
+func (q *Query) match(ser *series) bool {
+	if !q.Metric.Match(ser.name) {
+		return false
+	}
+	for _, tf := range q.Tags {
+		found := false
+		for i := 0; i+1 < len(ser.tags); i += 2 {
+			if ser.tags[i] == tf.Key && tf.Value.Match(ser.tags[i+1]) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+type bucket struct {
+	start int64
+	count int
+	value float64
+}
+
+func (b *bucket) add(agg Aggregation, value float64) {
+	switch {
+	case b.count == 0:
+		b.value = value
+	case agg == AggregationMin:
+		if value < b.value {
+			b.value = value
+		}
+	case agg == AggregationMax:
+		if value > b.value {
+			b.value = value
+		}
+	case agg == AggregationSum, agg == AggregationAvg:
+		b.value += value
+	case agg == AggregationLast:
+		b.value = value
+	}
+	b.count++
+}
+
+func (q *Query) points(ser *series) []Point {
+	var points []Point
+	if q.Step == 0 {
+		ser.ring.each(q.Start, q.End, func(ts int64, value float64) {
+			points = append(points, Point{ts, value})
+		})
+		return points
+	}
+
+	var b *bucket
+	finish := func() {
+		if b == nil {
+			return
+		}
+		if q.Aggregation == AggregationAvg {
+			b.value /= float64(b.count)
+		}
+		points = append(points, Point{b.start, b.value})
+	}
+	ser.ring.each(q.Start, q.End, func(ts int64, value float64) {
+		start := ts / q.Step * q.Step
+		if b == nil || b.start != start {
+			finish()
+			b = &bucket{start: start}
+		}
+		b.add(q.Aggregation, value)
+	})
+	finish()
+	return points
+}
+
+func tagMap(tags []string) map[string]string {
+	m := make(map[string]string)
+	for i := 0; i+1 < len(tags); i += 2 {
+		m[tags[i]] = tags[i+1]
+	}
+	return m
+}
+
+// Query returns the matching series which have points in the range, sorted
+// by name and tags.
+func (s *Store) Query(q *Query) []Result {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	var results []Result
+	var keys []string
+	for key, ser := range s.series {
+		if !q.match(ser) {
+			continue
+		}
+		points := q.points(ser)
+		if len(points) == 0 {
+			continue
+		}
+		keys = append(keys, key)
+		results = append(results, Result{
+			Name:   ser.name,
+			Tags:   tagMap(ser.tags),
+			Points: points,
+		})
+	}
+	sort.Sort(&byKey{keys, results})
+	return results
+}
+
+// Series returns the matching series, without their points.
+func (s *Store) Series(q *Query) []Result {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	var results []Result
+	var keys []string
+	for key, ser := range s.series {
+		if q.match(ser) {
+			keys = append(keys, key)
+			results = append(results, Result{
+				Name: ser.name,
+				Tags: tagMap(ser.tags),
+			})
+		}
+	}
+	sort.Sort(&byKey{keys, results})
+	return results
+}
+
+type byKey struct {
+	keys    []string
+	results []Result
+}
+
+func (bk *byKey) Len() int {
+	return len(bk.keys)
+}
+
+func (bk *byKey) Less(i, j int) bool {
+	return strings.Compare(bk.keys[i], bk.keys[j]) < 0
+}
+
+func (bk *byKey) Swap(i, j int) {
+	bk.keys[i], bk.keys[j] = bk.keys[j], bk.keys[i]
+	bk.results[i], bk.results[j] = bk.results[j], bk.results[i]
+}
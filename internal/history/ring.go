@@ -0,0 +1,60 @@
+package history
+
+// ring is a fixed size buffer of points, in time order, which overwrites the
+// oldest point once it is full.
+type ring struct {
+	stamps []int64
+	values []float64
+	next   int
+	count  int
+}
+
+func newRing(capacity int) *ring {
+	return &ring{
+		stamps: make([]int64, capacity),
+		values: make([]float64, capacity),
+	}
+}
+
+// add records value at ts, which is already rounded to the resolution.  A
+// point with the same timestamp as the newest point replaces it, or is added
+// to it if sum is set.  Points older than the newest point are ignored.
+func (r *ring) add(ts int64, value float64, sum bool) {
+	if r.count > 0 {
+		last := (r.next - 1 + len(r.stamps)) % len(r.stamps)
+		if r.stamps[last] == ts {
+			if sum {
+				r.values[last] += value
+			} else {
+				r.values[last] = value
+			}
+			return
+		} else if ts < r.stamps[last] {
+			return
+		}
+	}
+	r.stamps[r.next] = ts
+	r.values[r.next] = value
+	r.next = (r.next + 1) % len(r.stamps)
+	if r.count < len(r.stamps) {
+		r.count++
+	}
+}
+
+// each calls fn with every point from start to end inclusive, oldest first.
+func (r *ring) each(start, end int64, fn func(ts int64, value float64)) {
+	first := (r.next - r.count + len(r.stamps)) % len(r.stamps)
+	for i := 0; i < r.count; i++ {
+		idx := (first + i) % len(r.stamps)
+		if ts := r.stamps[idx]; ts >= start && ts <= end {
+			fn(ts, r.values[idx])
+		}
+	}
+}
+
+func (r *ring) newest() int64 {
+	if r.count == 0 {
+		return 0
+	}
+	return r.stamps[(r.next-1+len(r.stamps))%len(r.stamps)]
+}
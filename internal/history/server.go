@@ -0,0 +1,138 @@
+package history
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/squizzling/glob/pkg/glob"
+	"go.uber.org/zap"
+)
+
+// Server serves the history over HTTP:
+//
+//	GET /api/v1/query?metric=glob&tag=key=glob&start=&end=&step=&agg=
+//	GET /api/v1/series?metric=glob&tag=key=glob
+//
+// start and end are unix seconds, RFC3339 times, or durations relative to
+// now such as -1h.  They default to the last hour.  step is a duration, and
+// agg is one of avg (the default), min, max, sum, or last.
+type Server struct {
+	logger *zap.Logger
+	store  *Store
+}
+
+func NewServer(logger *zap.Logger, store *Store) *Server {
+	return &Server{
+		logger: logger,
+		store:  store,
+	}
+}
+
+// ListenAndServe serves the API on address until it fails.
+func (srv *Server) ListenAndServe(address string) error {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v1/query", srv.handleQuery)
+	mux.HandleFunc("/api/v1/series", srv.handleSeries)
+	return http.ListenAndServe(address, mux)
+}
+
+func parseTime(s string, now time.Time, def time.Time) (int64, error) {
+	if s == "" {
+		return def.Unix(), nil
+	}
+	if ts, err := strconv.ParseInt(s, 10, 64); err == nil {
+		return ts, nil
+	}
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t.Unix(), nil
+	}
+	if d, err := time.ParseDuration(s); err == nil {
+		return now.Add(d).Unix(), nil
+	}
+	return 0, fmt.Errorf("invalid time %s", s)
+}
+
+func parseQuery(r *http.Request) (*Query, error) {
+	values := r.URL.Query()
+	now := time.Now()
+
+	metric := values.Get("metric")
+	if metric == "" {
+		metric = "*"
+	}
+	q := &Query{
+		Metric:      glob.NewACL([]string{metric}, nil, false),
+		Aggregation: AggregationAvg,
+	}
+
+	for _, tag := range values["tag"] {
+		eq := strings.IndexByte(tag, '=')
+		if eq <= 0 {
+			return nil, fmt.Errorf("tag must be in the form key=glob")
+		}
+		q.Tags = append(q.Tags, TagFilter{
+			Key:   tag[:eq],
+			Value: glob.NewACL([]string{tag[eq+1:]}, nil, false),
+		})
+	}
+
+	var err error
+	if q.Start, err = parseTime(values.Get("start"), now, now.Add(-1*time.Hour)); err != nil {
+		return nil, err
+	}
+	if q.End, err = parseTime(values.Get("end"), now, now); err != nil {
+		return nil, err
+	}
+
+	if step := values.Get("step"); step != "" {
+		d, err := time.ParseDuration(step)
+		if err != nil || d < time.Second {
+			return nil, fmt.Errorf("step must be a duration of at least 1s")
+		}
+		q.Step = int64(d / time.Second)
+	}
+	if agg := values.Get("agg"); agg != "" {
+		if q.Aggregation, err = ParseAggregation(agg); err != nil {
+			return nil, err
+		}
+	}
+	return q, nil
+}
+
+type response struct {
+	Series []Result `json:"series,omitempty"`
+	Error  string   `json:"error,omitempty"`
+}
+
+func (srv *Server) write(w http.ResponseWriter, status int, resp *response) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		srv.logger.Debug("failed to write response", zap.Error(err))
+	}
+}
+
+func (srv *Server) handle(w http.ResponseWriter, r *http.Request, fn func(q *Query) []Result) {
+	if r.Method != http.MethodGet {
+		srv.write(w, http.StatusMethodNotAllowed, &response{Error: "method not allowed"})
+		return
+	}
+	q, err := parseQuery(r)
+	if err != nil {
+		srv.write(w, http.StatusBadRequest, &response{Error: err.Error()})
+		return
+	}
+	srv.write(w, http.StatusOK, &response{Series: fn(q)})
+}
+
+func (srv *Server) handleQuery(w http.ResponseWriter, r *http.Request) {
+	srv.handle(w, r, srv.store.Query)
+}
+
+func (srv *Server) handleSeries(w http.ResponseWriter, r *http.Request) {
+	srv.handle(w, r, srv.store.Series)
+}
@@ -0,0 +1,197 @@
+// Package history keeps recent samples in memory, and serves them over a
+// small HTTP query API.
+package history
+
+import (
+	"encoding/gob"
+	"fmt"
+	"io/ioutil"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// seriesOverhead is a rough estimate of the memory used by a series, in
+// addition to its points.
+const seriesOverhead = 256
+
+type series struct {
+	name  string
+	tags  []string
+	count bool
+	ring  *ring
+}
+
+// Store is the history of every series, each a ring of points at a fixed
+// resolution.  Once the store reaches its maximum number of series, series
+// with no points within the retention are evicted, and if there are none,
+// samples for new series are dropped.
+type Store struct {
+	resolution int64
+	retention  int64
+	capacity   int
+	maxSeries  int
+
+	lock      sync.RWMutex
+	series    map[string]*series
+	dropped   int64
+	evicted   int64
+	lastEvict int64
+}
+
+func NewStore(opts *HistoryOpts) *Store {
+	capacity := int(opts.Retention / opts.Resolution)
+	maxSeries := int(opts.MaxMemory / int64(capacity*16+seriesOverhead))
+	if maxSeries < 1 {
+		maxSeries = 1
+	}
+	return &Store{
+		resolution: int64(opts.Resolution / time.Second),
+		retention:  int64(opts.Retention / time.Second),
+		capacity:   capacity,
+		maxSeries:  maxSeries,
+		series:     make(map[string]*series),
+	}
+}
+
+func seriesKey(name string, tags []string) string {
+	return name + "|" + strings.Join(tags, "||")
+}
+
+// Add records a sample.  Counts are summed within the resolution, and gauges
+// keep the last value.  NaN and infinite values are ignored.
+func (s *Store) Add(name string, tags []string, count bool, t time.Time, value float64) {
+	if math.IsNaN(value) || math.IsInf(value, 0) {
+		return
+	}
+	ts := t.Unix() / s.resolution * s.resolution
+	key := seriesKey(name, tags)
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	ser, ok := s.series[key]
+	if !ok {
+		if len(s.series) >= s.maxSeries {
+			s.evict(ts)
+		}
+		if len(s.series) >= s.maxSeries {
+			s.dropped++
+			return
+		}
+		ser = &series{
+			name:  name,
+			tags:  tags,
+			count: count,
+			ring:  newRing(s.capacity),
+		}
+		s.series[key] = ser
+	}
+	ser.ring.add(ts, value, count)
+}
+
+// evict removes the series with no points within the retention of ts.  It
+// scans every series, so it runs at most once per resolution, however many
+// samples for new series arrive while the store is full.
+func (s *Store) evict(ts int64) {
+	if ts < s.lastEvict+s.resolution {
+		return
+	}
+	s.lastEvict = ts
+	for key, ser := range s.series {
+		if ser.ring.newest() <= ts-s.retention {
+			delete(s.series, key)
+			s.evicted++
+		}
+	}
+}
+
+// Stats returns the number of series, and the samples dropped and the series
+// evicted since the last call because the store was full.
+func (s *Store) Stats() (int, int64, int64) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	dropped, evicted := s.dropped, s.evicted
+	s.dropped, s.evicted = 0, 0
+	return len(s.series), dropped, evicted
+}
+
+// snapshot is the persisted form of the store.
+type snapshot struct {
+	Resolution int64
+	Series     []snapshotSeries
+}
+
+type snapshotSeries struct {
+	Name   string
+	Tags   []string
+	Count  bool
+	Stamps []int64
+	Values []float64
+}
+
+// Save writes the store to filename, replacing it atomically.
+func (s *Store) Save(filename string) error {
+	snap := snapshot{
+		Resolution: s.resolution,
+	}
+	s.lock.RLock()
+	for _, ser := range s.series {
+		ss := snapshotSeries{
+			Name:  ser.name,
+			Tags:  ser.tags,
+			Count: ser.count,
+		}
+		ser.ring.each(0, ser.ring.newest(), func(ts int64, value float64) {
+			ss.Stamps = append(ss.Stamps, ts)
+			ss.Values = append(ss.Values, value)
+		})
+		snap.Series = append(snap.Series, ss)
+	}
+	s.lock.RUnlock()
+
+	tmp, err := ioutil.TempFile(filepath.Dir(filename), filepath.Base(filename)+".tmp")
+	if err != nil {
+		return err
+	}
+	if err := gob.NewEncoder(tmp).Encode(&snap); err != nil {
+		_ = tmp.Close()
+		_ = os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		_ = os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), filename)
+}
+
+// Load adds the samples in filename to the store.  A missing file is not an
+// error, as there is nothing to load on the first run.
+func (s *Store) Load(filename string) error {
+	f, err := os.Open(filename)
+	if os.IsNotExist(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	defer func() {
+		_ = f.Close()
+	}()
+
+	var snap snapshot
+	if err := gob.NewDecoder(f).Decode(&snap); err != nil {
+		return err
+	}
+	if snap.Resolution != s.resolution {
+		return fmt.Errorf("resolution of %ds does not match %ds", snap.Resolution, s.resolution)
+	}
+	for _, ss := range snap.Series {
+		for idx, ts := range ss.Stamps {
+			s.Add(ss.Name, ss.Tags, ss.Count, time.Unix(ts, 0), ss.Values[idx])
+		}
+	}
+	return nil
+}
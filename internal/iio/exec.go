@@ -3,19 +3,38 @@ package iio
 import (
 	"bytes"
 	"context"
+	"errors"
 	"os/exec"
 	"time"
 )
 
+// ErrTimeout is returned by ExecuteTimeout and ExecuteContext when the command
+// was killed for running too long.
+var ErrTimeout = errors.New("command timed out")
+
 func Execute(command string, args ...string) ([]byte, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	return ExecuteTimeout(1*time.Second, command, args...)
+}
+
+func ExecuteTimeout(timeout time.Duration, command string, args ...string) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
 	defer cancel()
+	return ExecuteContext(ctx, command, args...)
+}
 
+// ExecuteContext runs command until it exits or ctx is done, whichever comes
+// first.  Commands killed because ctx was cancelled return ctx.Err().
+func ExecuteContext(ctx context.Context, command string, args ...string) ([]byte, error) {
 	cmd := exec.CommandContext(ctx, command, args...)
 	outputBuffer := &bytes.Buffer{}
 	cmd.Stdout = outputBuffer
 	err := cmd.Run()
 	if err != nil {
+		if ctx.Err() == context.DeadlineExceeded {
+			return nil, ErrTimeout
+		} else if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
 		return nil, err
 	}
 	return outputBuffer.Bytes(), nil
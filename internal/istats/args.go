@@ -0,0 +1,38 @@
+package istats
+
+import (
+	"time"
+)
+
+type CardinalityOpts struct {
+	MaxSeries          int           `long:"cardinality.max-series"            default:"10000" description:"maximum distinct series per emitter, 0 for unlimited"`
+	MaxSeriesPerMetric int           `long:"cardinality.max-series-per-metric" default:"1000"  description:"maximum distinct series per metric name, 0 for unlimited"`
+	Overflow           string        `long:"cardinality.overflow"              default:"drop"  description:"what to do with new series over the limit" choice:"drop" choice:"other"`
+	Expiry             time.Duration `long:"cardinality.expiry"                default:"10m"   description:"time after which an unused series no longer counts towards the limits"`
+}
+
+func (opts *CardinalityOpts) Validate() []string {
+	var errs []string
+	if opts.MaxSeries < 0 {
+		errs = append(errs, "cardinality.max-series must not be negative")
+	}
+	if opts.MaxSeriesPerMetric < 0 {
+		errs = append(errs, "cardinality.max-series-per-metric must not be negative")
+	}
+	if opts.Expiry <= 0 {
+		errs = append(errs, "cardinality.expiry must be positive")
+	}
+	return errs
+}
+
+type PoolOpts struct {
+	IdleTimeout time.Duration `long:"pool.idle-timeout" default:"10m" description:"time after which the client for an unused set of tags is discarded"`
+	Tombstone   bool          `long:"pool.tombstone"                  description:"send zero for every gauge of a discarded client, so the series doesn't hold its last value"`
+}
+
+func (opts *PoolOpts) Validate() []string {
+	if opts.IdleTimeout <= 0 {
+		return []string{"pool.idle-timeout must be positive"}
+	}
+	return nil
+}
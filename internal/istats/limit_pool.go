@@ -0,0 +1,249 @@
+package istats
+
+import (
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/squizzling/stats/pkg/statser"
+)
+
+var _ = statser.Pool(&LimitPool{})
+var _ = statser.TimestampStatser(&limitStatser{})
+var _ = statser.DescribedStatser(&limitStatser{})
+
+// overflowValue replaces every tag value of a series which is collapsed.
+const overflowValue = "other"
+
+// warningInterval is the minimum time between warnings about the limits.
+const warningInterval = 1 * time.Minute
+
+// LimitPool is a statser.Pool which limits the number of distinct series an
+// emitter can write, both in total and per metric name.  Once a limit is hit,
+// new series are either dropped, or have every tag value replaced with
+// "other", so they collapse into a single series per metric.  Series which
+// aren't written for the expiry time stop counting towards the limits.
+type LimitPool struct {
+	logger             *zap.Logger
+	pool               statser.Pool
+	emitterName        string
+	maxSeries          int
+	maxSeriesPerMetric int
+	collapse           bool
+	expiry             time.Duration
+
+	lock      sync.Mutex
+	series    map[string]*limitSeries
+	perMetric map[string]int
+	lastSweep time.Time
+
+	dropped            int64
+	collapsed          int64
+	lastWarning        time.Time
+	overflowsSinceWarn int64
+}
+
+type limitSeries struct {
+	metricName string
+	lastSeen   time.Time
+}
+
+func NewLimitPool(logger *zap.Logger, pool statser.Pool, emitterName string, opts *CardinalityOpts) *LimitPool {
+	return &LimitPool{
+		logger:             logger,
+		pool:               pool,
+		emitterName:        emitterName,
+		maxSeries:          opts.MaxSeries,
+		maxSeriesPerMetric: opts.MaxSeriesPerMetric,
+		collapse:           opts.Overflow == "other",
+		expiry:             opts.Expiry,
+		series:             make(map[string]*limitSeries),
+		perMetric:          make(map[string]int),
+		lastSweep:          time.Now(),
+	}
+}
+
+func (lp *LimitPool) Host(tags ...string) statser.Statser {
+	return &limitStatser{
+		pool: lp,
+		host: true,
+		tags: tags,
+	}
+}
+
+func (lp *LimitPool) Global(tags ...string) statser.Statser {
+	return &limitStatser{
+		pool: lp,
+		tags: tags,
+	}
+}
+
+func seriesKey(metricName string, host bool, tags []string) string {
+	scope := "g"
+	if host {
+		scope = "h"
+	}
+	return metricName + "|" + scope + "|" + strings.Join(tags, "||")
+}
+
+// sweep forgets series which haven't been seen within the expiry time.  It
+// must be called with the lock held.
+func (lp *LimitPool) sweep(now time.Time) {
+	if now.Sub(lp.lastSweep) < lp.expiry/10 {
+		return
+	}
+	lp.lastSweep = now
+	for key, s := range lp.series {
+		if now.Sub(s.lastSeen) > lp.expiry {
+			delete(lp.series, key)
+			if lp.perMetric[s.metricName]--; lp.perMetric[s.metricName] == 0 {
+				delete(lp.perMetric, s.metricName)
+			}
+		}
+	}
+}
+
+func (lp *LimitPool) track(key, metricName string, now time.Time) {
+	lp.series[key] = &limitSeries{
+		metricName: metricName,
+		lastSeen:   now,
+	}
+	lp.perMetric[metricName]++
+}
+
+// admit returns the tags to write the series with, or false if it should be
+// dropped.
+func (lp *LimitPool) admit(metricName string, host bool, tags []string) ([]string, bool) {
+	lp.lock.Lock()
+	defer lp.lock.Unlock()
+
+	now := time.Now()
+	lp.sweep(now)
+
+	key := seriesKey(metricName, host, tags)
+	if s, ok := lp.series[key]; ok {
+		s.lastSeen = now
+		return tags, true
+	}
+
+	overTotal := lp.maxSeries != 0 && len(lp.series) >= lp.maxSeries
+	overMetric := lp.maxSeriesPerMetric != 0 && lp.perMetric[metricName] >= lp.maxSeriesPerMetric
+	if !overTotal && !overMetric {
+		lp.track(key, metricName, now)
+		return tags, true
+	}
+
+	lp.overflowsSinceWarn++
+	if !lp.collapse || len(tags) == 0 {
+		lp.dropped++
+		return nil, false
+	}
+
+	// The collapsed series is always admitted, as there can only be one per
+	// metric and set of tag keys.
+	collapsedTags := make([]string, len(tags))
+	for idx := range tags {
+		if idx%2 == 0 {
+			collapsedTags[idx] = tags[idx]
+		} else {
+			collapsedTags[idx] = overflowValue
+		}
+	}
+	collapsedKey := seriesKey(metricName, host, collapsedTags)
+	if s, ok := lp.series[collapsedKey]; ok {
+		s.lastSeen = now
+	} else {
+		lp.track(collapsedKey, metricName, now)
+	}
+	lp.collapsed++
+	return collapsedTags, true
+}
+
+// Report writes the self-metrics for the limits, and logs a warning if any
+// series have overflowed since the last warning, no more than once a minute.
+func (lp *LimitPool) Report() {
+	lp.lock.Lock()
+	seriesCount := len(lp.series)
+	dropped, collapsed := lp.dropped, lp.collapsed
+	lp.dropped, lp.collapsed = 0, 0
+	var overflows int64
+	if lp.overflowsSinceWarn > 0 && time.Since(lp.lastWarning) >= warningInterval {
+		overflows = lp.overflowsSinceWarn
+		lp.overflowsSinceWarn = 0
+		lp.lastWarning = time.Now()
+	}
+	lp.lock.Unlock()
+
+	c := lp.pool.Host("emitter", lp.emitterName)
+	c.Gauge("stats.cardinality.series", seriesCount)
+	c.Count("stats.cardinality.dropped", dropped)
+	c.Count("stats.cardinality.collapsed", collapsed)
+
+	if overflows > 0 {
+		lp.logger.Warn(
+			"series limit reached",
+			zap.String("emitter", lp.emitterName),
+			zap.Int64("overflows", overflows),
+			zap.Int("series", seriesCount),
+			zap.Bool("collapsed", lp.collapse),
+		)
+	}
+}
+
+type limitStatser struct {
+	pool      *LimitPool
+	host      bool
+	tags      []string
+	timestamp time.Time
+	kind      string
+	unit      string
+}
+
+func (ls *limitStatser) WithTimestamp(t time.Time) statser.Statser {
+	c := *ls
+	c.timestamp = t
+	return &c
+}
+
+func (ls *limitStatser) WithDescription(kind, unit string) statser.Statser {
+	c := *ls
+	c.kind = kind
+	c.unit = unit
+	return &c
+}
+
+func (ls *limitStatser) statser(metricName string) statser.Statser {
+	tags, ok := ls.pool.admit(metricName, ls.host, ls.tags)
+	if !ok {
+		return nil
+	}
+	var c statser.Statser
+	if ls.host {
+		c = ls.pool.pool.Host(tags...)
+	} else {
+		c = ls.pool.pool.Global(tags...)
+	}
+	if !ls.timestamp.IsZero() {
+		c = statser.At(c, ls.timestamp)
+	}
+	if ls.kind != "" || ls.unit != "" {
+		if ds, ok := c.(statser.DescribedStatser); ok {
+			c = ds.WithDescription(ls.kind, ls.unit)
+		}
+	}
+	return c
+}
+
+func (ls *limitStatser) Gauge(metricName string, value interface{}) {
+	if c := ls.statser(metricName); c != nil {
+		c.Gauge(metricName, value)
+	}
+}
+
+func (ls *limitStatser) Count(metricName string, value interface{}) {
+	if c := ls.statser(metricName); c != nil {
+		c.Count(metricName, value)
+	}
+}
@@ -0,0 +1,156 @@
+package istats
+
+import (
+	"sync"
+	"time"
+
+	"github.com/squizzling/stats/pkg/statser"
+)
+
+var _ = statser.Pool(&MemoryPool{})
+var _ = statser.TimestampStatser(&memoryStatser{})
+var _ = statser.DescribedStatser(&memoryStatser{})
+
+// Sample is a single value captured by a MemoryPool.  Unit is empty unless
+// the sample was described by the emitter.
+type Sample struct {
+	Name  string
+	Kind  string
+	Unit  string
+	Tags  []string
+	Value float64
+
+	// Timestamp is zero unless the sample was sent through statser.At.
+	Timestamp time.Time
+}
+
+const (
+	KindGauge = "gauge"
+	KindCount = "count"
+
+	// KindCumulative is a gauge described as a cumulative counter.
+	KindCumulative = "cumulative"
+)
+
+// MemoryPool is a statser.Pool which captures everything sent to it, for
+// modes which evaluate or display stats locally instead of forwarding them.
+type MemoryPool struct {
+	hostName string
+
+	lock    sync.Mutex
+	samples []Sample
+}
+
+func NewMemoryPool(hostName string) *MemoryPool {
+	return &MemoryPool{
+		hostName: hostName,
+	}
+}
+
+func (mp *MemoryPool) Host(tags ...string) statser.Statser {
+	return mp.Global(append([]string{"host", mp.hostName}, tags...)...)
+}
+
+func (mp *MemoryPool) Global(tags ...string) statser.Statser {
+	return &memoryStatser{
+		pool: mp,
+		tags: tags,
+	}
+}
+
+// Drain returns every sample captured since the last call to Drain.
+func (mp *MemoryPool) Drain() []Sample {
+	mp.lock.Lock()
+	defer mp.lock.Unlock()
+	samples := mp.samples
+	mp.samples = nil
+	return samples
+}
+
+func (mp *MemoryPool) add(s Sample) {
+	mp.lock.Lock()
+	mp.samples = append(mp.samples, s)
+	mp.lock.Unlock()
+}
+
+type memoryStatser struct {
+	pool      *MemoryPool
+	tags      []string
+	timestamp time.Time
+	kind      string
+	unit      string
+}
+
+func (ms *memoryStatser) WithTimestamp(t time.Time) statser.Statser {
+	c := *ms
+	c.timestamp = t
+	return &c
+}
+
+func (ms *memoryStatser) WithDescription(kind, unit string) statser.Statser {
+	c := *ms
+	c.kind = kind
+	c.unit = unit
+	return &c
+}
+
+func (ms *memoryStatser) Gauge(metricName string, value interface{}) {
+	if ms.kind == KindCumulative {
+		ms.record(KindCumulative, metricName, value)
+	} else {
+		ms.record(KindGauge, metricName, value)
+	}
+}
+
+func (ms *memoryStatser) Count(metricName string, value interface{}) {
+	ms.record(KindCount, metricName, value)
+}
+
+func (ms *memoryStatser) record(kind, metricName string, value interface{}) {
+	v, ok := ToFloat64(value)
+	if !ok {
+		return
+	}
+	ms.pool.add(Sample{
+		Name:  metricName,
+		Kind:  kind,
+		Unit:  ms.unit,
+		Tags:  ms.tags,
+		Value: v,
+
+		Timestamp: ms.timestamp,
+	})
+}
+
+// ToFloat64 converts the numeric values accepted by statser.Statser to a
+// float64.  It returns false for anything which isn't a number.
+func ToFloat64(value interface{}) (float64, bool) {
+	switch v := value.(type) {
+	case float64:
+		return v, true
+	case float32:
+		return float64(v), true
+	case int:
+		return float64(v), true
+	case int8:
+		return float64(v), true
+	case int16:
+		return float64(v), true
+	case int32:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	case uint:
+		return float64(v), true
+	case uint8:
+		return float64(v), true
+	case uint16:
+		return float64(v), true
+	case uint32:
+		return float64(v), true
+	case uint64:
+		return float64(v), true
+	default:
+		return 0, false
+	}
+}
@@ -2,26 +2,46 @@ package istats
 
 import (
 	"strings"
+	"sync"
+	"time"
 
-	"github.com/alexcesaro/statsd"
-
+	"github.com/squizzling/stats/internal/statsd"
 	"github.com/squizzling/stats/pkg/statser"
 )
 
 var _ = statser.Pool(&Pool{})
 var _ = statser.Statser(&statsd.Client{})
+var _ = statser.TimestampStatser(&poolStatser{})
 
+// Pool is a statser.Pool which clones a statsd.Client for each set of tags.
+// Clients which haven't been used for the idle timeout are discarded by
+// Expire, optionally sending a zero for each gauge written through them.  It
+// is safe for concurrent use.
 type Pool struct {
-	hostName string
-	base     *statsd.Client
-	clients  map[string]*statsd.Client
+	hostName    string
+	base        *statsd.Client
+	idleTimeout time.Duration
+	tombstone   bool
+
+	lock      sync.Mutex
+	clients   map[string]*poolClient
+	lastSweep time.Time
+}
+
+type poolClient struct {
+	client   *statsd.Client
+	lastUsed time.Time
+	gauges   map[string]struct{}
 }
 
-func NewPool(hostName string, c *statsd.Client) *Pool {
+func NewPool(hostName string, c *statsd.Client, opts *PoolOpts) *Pool {
 	return &Pool{
-		hostName: hostName,
-		base:     c,
-		clients:  map[string]*statsd.Client{},
+		hostName:    hostName,
+		base:        c,
+		idleTimeout: opts.IdleTimeout,
+		tombstone:   opts.Tombstone,
+		clients:     map[string]*poolClient{},
+		lastSweep:   time.Now(),
 	}
 }
 
@@ -31,10 +51,86 @@ func (p *Pool) Host(tags ...string) statser.Statser {
 
 func (p *Pool) Global(tags ...string) statser.Statser {
 	s := strings.Join(tags, "||")
-	if c, ok := p.clients[s]; ok {
-		return c
+
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	pc, ok := p.clients[s]
+	if !ok {
+		pc = &poolClient{
+			client: p.base.Clone(tags...),
+		}
+		if p.tombstone {
+			pc.gauges = map[string]struct{}{}
+		}
+		p.clients[s] = pc
+	}
+	pc.lastUsed = time.Now()
+	return &poolStatser{
+		pool:   p,
+		pc:     pc,
+		client: pc.client,
+	}
+}
+
+func (p *Pool) touch(pc *poolClient, gaugeName string) {
+	p.lock.Lock()
+	pc.lastUsed = time.Now()
+	if pc.gauges != nil && gaugeName != "" {
+		pc.gauges[gaugeName] = struct{}{}
+	}
+	p.lock.Unlock()
+}
+
+// Expire discards the clients which haven't been used within the idle
+// timeout.  It only scans the clients every tenth of the idle timeout, so it
+// is cheap to call every tick.
+func (p *Pool) Expire() {
+	now := time.Now()
+	var expired []*poolClient
+
+	p.lock.Lock()
+	if now.Sub(p.lastSweep) < p.idleTimeout/10 {
+		p.lock.Unlock()
+		return
 	}
-	c := p.base.Clone(statsd.Tags(tags...))
-	p.clients[s] = c
-	return c
+	p.lastSweep = now
+	for key, pc := range p.clients {
+		if now.Sub(pc.lastUsed) > p.idleTimeout {
+			delete(p.clients, key)
+			expired = append(expired, pc)
+		}
+	}
+	p.lock.Unlock()
+
+	// A statser held by an emitter keeps working after its client is
+	// discarded, as clones share the connection of the base client.
+	for _, pc := range expired {
+		for gaugeName := range pc.gauges {
+			pc.client.Gauge(gaugeName, 0)
+		}
+	}
+}
+
+type poolStatser struct {
+	pool   *Pool
+	pc     *poolClient
+	client *statsd.Client
+}
+
+func (ps *poolStatser) WithTimestamp(t time.Time) statser.Statser {
+	return &poolStatser{
+		pool:   ps.pool,
+		pc:     ps.pc,
+		client: ps.client.WithTimestamp(t),
+	}
+}
+
+func (ps *poolStatser) Gauge(metricName string, value interface{}) {
+	ps.pool.touch(ps.pc, metricName)
+	ps.client.Gauge(metricName, value)
+}
+
+func (ps *poolStatser) Count(metricName string, value interface{}) {
+	ps.pool.touch(ps.pc, "")
+	ps.client.Count(metricName, value)
 }
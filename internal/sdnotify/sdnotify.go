@@ -0,0 +1,73 @@
+// Package sdnotify implements the systemd service notification protocol,
+// by writing datagrams to the unix socket in NOTIFY_SOCKET.
+package sdnotify
+
+import (
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Notifier sends notifications to the service manager.  A nil Notifier
+// discards every notification, so callers don't need to check whether they
+// are running under systemd.
+type Notifier struct {
+	addr *net.UnixAddr
+}
+
+// New returns a Notifier for the socket in NOTIFY_SOCKET, or nil if it isn't
+// set.  A socket starting with @ is in the abstract namespace.
+func New() *Notifier {
+	socket := os.Getenv("NOTIFY_SOCKET")
+	if socket == "" {
+		return nil
+	}
+	if strings.HasPrefix(socket, "@") {
+		socket = "\x00" + socket[1:]
+	}
+	return &Notifier{
+		addr: &net.UnixAddr{
+			Name: socket,
+			Net:  "unixgram",
+		},
+	}
+}
+
+// Notify sends each state, such as READY=1, in a single datagram.
+func (n *Notifier) Notify(states ...string) error {
+	if n == nil {
+		return nil
+	}
+	conn, err := net.DialUnix("unixgram", nil, n.addr)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = conn.Close()
+	}()
+	_, err = conn.Write([]byte(strings.Join(states, "\n")))
+	return err
+}
+
+func (n *Notifier) Ready(status string) error {
+	return n.Notify("READY=1", "STATUS="+status)
+}
+
+func (n *Notifier) Watchdog(status string) error {
+	return n.Notify("WATCHDOG=1", "STATUS="+status)
+}
+
+// WatchdogInterval returns the watchdog timeout from WATCHDOG_USEC, if the
+// watchdog is enabled for this process.
+func WatchdogInterval() (time.Duration, bool) {
+	usec, err := strconv.ParseInt(os.Getenv("WATCHDOG_USEC"), 10, 64)
+	if err != nil || usec <= 0 {
+		return 0, false
+	}
+	if pid := os.Getenv("WATCHDOG_PID"); pid != "" && pid != strconv.Itoa(os.Getpid()) {
+		return 0, false
+	}
+	return time.Duration(usec) * time.Microsecond, true
+}
@@ -0,0 +1,25 @@
+package statsd
+
+import (
+	"time"
+)
+
+type SpoolOpts struct {
+	Directory string        `long:"spool.directory"                    description:"directory to buffer metrics in while a tcp or unixstream target is unreachable"`
+	MaxSize   int64         `long:"spool.max-size" default:"104857600" description:"maximum size of the spool in bytes, the oldest metrics are dropped first"`
+	MaxAge    time.Duration `long:"spool.max-age"  default:"24h"       description:"metrics older than this are dropped instead of replayed"`
+}
+
+func (opts *SpoolOpts) Validate() []string {
+	if opts.Directory == "" {
+		return nil
+	}
+	var errs []string
+	if opts.MaxSize < 1024*1024 {
+		errs = append(errs, "spool.max-size must be at least 1048576")
+	}
+	if opts.MaxAge <= 0 {
+		errs = append(errs, "spool.max-age must be positive")
+	}
+	return errs
+}
@@ -0,0 +1,205 @@
+// Package statsd is a statsd client which supports UDP, TCP and unix socket
+// targets, and the Datadog, InfluxDB and Graphite tag formats.
+package statsd
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// TagFormat is how tags are added to a metric.
+type TagFormat string
+
+const (
+	// Datadog appends tags, as in name:1|g|#key:value.  A tag with an empty
+	// value is written bare, as in name:1|g|#key
+	Datadog = TagFormat("datadog")
+
+	// InfluxDB adds tags to the name, as in name,key=value:1|g
+	InfluxDB = TagFormat("influxdb")
+
+	// Graphite adds tags to the name, as in name;key=value:1|g
+	Graphite = TagFormat("graphite")
+)
+
+func ParseTagFormat(s string) (TagFormat, error) {
+	switch tf := TagFormat(s); tf {
+	case Datadog, InfluxDB, Graphite:
+		return tf, nil
+	default:
+		return "", fmt.Errorf("unknown tag format %s, expected datadog, influxdb, or graphite", s)
+	}
+}
+
+type Config struct {
+	Network       string
+	Address       string
+	TagFormat     TagFormat
+	MaxPacketSize int
+	FlushPeriod   time.Duration
+
+	// ErrorHandler is called with every dial, write, and spool error.
+	ErrorHandler func(error)
+
+	// Spool is used to buffer metrics on disk while a stream target is
+	// unreachable, if its Directory is set.
+	Spool *SpoolOpts
+}
+
+// Client writes metrics with a fixed set of tags.  Clients created by Clone
+// share the connection of the client they were cloned from, and are safe for
+// concurrent use.
+type Client struct {
+	conn      *conn
+	tagFormat TagFormat
+	tags      []string
+	timestamp int64
+
+	// nameSuffix is added after the metric name, and lineSuffix after the
+	// type, depending on the tag format.
+	nameSuffix string
+	lineSuffix string
+}
+
+func New(cfg *Config) (*Client, error) {
+	var sp *spool
+	if cfg.Spool != nil && cfg.Spool.Directory != "" {
+		var err error
+		if sp, err = openSpool(cfg.Spool); err != nil {
+			return nil, err
+		}
+	}
+	return newClient(newConn(cfg, sp), cfg.TagFormat, nil), nil
+}
+
+func newClient(c *conn, tagFormat TagFormat, tags []string) *Client {
+	client := &Client{
+		conn:      c,
+		tagFormat: tagFormat,
+		tags:      tags,
+	}
+	if len(tags) == 0 {
+		return client
+	}
+
+	sb := strings.Builder{}
+	for i := 0; i+1 < len(tags); i += 2 {
+		switch tagFormat {
+		case InfluxDB:
+			sb.WriteByte(',')
+			sb.WriteString(tags[i])
+			sb.WriteByte('=')
+		case Graphite:
+			sb.WriteByte(';')
+			sb.WriteString(tags[i])
+			sb.WriteByte('=')
+		default:
+			if i == 0 {
+				sb.WriteString("|#")
+			} else {
+				sb.WriteByte(',')
+			}
+			sb.WriteString(tags[i])
+			if tags[i+1] == "" {
+				// A bare tag, which has no value.
+				continue
+			}
+			sb.WriteByte(':')
+		}
+		sb.WriteString(tags[i+1])
+	}
+	if tagFormat == InfluxDB || tagFormat == Graphite {
+		client.nameSuffix = sb.String()
+	} else {
+		client.lineSuffix = sb.String()
+	}
+	return client
+}
+
+// Clone returns a client which writes with the tags of c, followed by tags.
+func (c *Client) Clone(tags ...string) *Client {
+	return newClient(c.conn, c.tagFormat, append(append([]string{}, c.tags...), tags...))
+}
+
+// WithTimestamp returns a copy of c which records t as the time of each
+// metric.  The timestamp is only sent when metrics are replayed from the
+// spool, and then only in the Datadog format.
+func (c *Client) WithTimestamp(t time.Time) *Client {
+	clone := *c
+	clone.timestamp = t.Unix()
+	return &clone
+}
+
+func (c *Client) Gauge(metricName string, value interface{}) {
+	c.send(metricName, value, "g")
+}
+
+func (c *Client) Count(metricName string, value interface{}) {
+	c.send(metricName, value, "c")
+}
+
+func appendNumber(buf []byte, value interface{}) ([]byte, bool) {
+	switch v := value.(type) {
+	case float64:
+		return strconv.AppendFloat(buf, v, 'f', -1, 64), true
+	case float32:
+		return strconv.AppendFloat(buf, float64(v), 'f', -1, 32), true
+	case int:
+		return strconv.AppendInt(buf, int64(v), 10), true
+	case int8:
+		return strconv.AppendInt(buf, int64(v), 10), true
+	case int16:
+		return strconv.AppendInt(buf, int64(v), 10), true
+	case int32:
+		return strconv.AppendInt(buf, int64(v), 10), true
+	case int64:
+		return strconv.AppendInt(buf, v, 10), true
+	case uint:
+		return strconv.AppendUint(buf, uint64(v), 10), true
+	case uint8:
+		return strconv.AppendUint(buf, uint64(v), 10), true
+	case uint16:
+		return strconv.AppendUint(buf, uint64(v), 10), true
+	case uint32:
+		return strconv.AppendUint(buf, uint64(v), 10), true
+	case uint64:
+		return strconv.AppendUint(buf, v, 10), true
+	default:
+		return buf, false
+	}
+}
+
+func (c *Client) send(metricName string, value interface{}, metricType string) {
+	line := make([]byte, 0, len(metricName)+len(c.nameSuffix)+len(c.lineSuffix)+24)
+	line = append(line, metricName...)
+	line = append(line, c.nameSuffix...)
+	line = append(line, ':')
+	line, ok := appendNumber(line, value)
+	if !ok {
+		c.conn.handleError(fmt.Errorf("unsupported value %v (%T) for %s", value, value, metricName))
+		return
+	}
+	line = append(line, '|')
+	line = append(line, metricType...)
+	line = append(line, c.lineSuffix...)
+	c.conn.write(line, c.timestamp)
+}
+
+// SpoolStats returns the spool counters since the last call, which are all
+// zero if there is no spool.
+func (c *Client) SpoolStats() SpoolStats {
+	return c.conn.spoolStats()
+}
+
+// Flush writes any buffered metrics immediately.
+func (c *Client) Flush() {
+	c.conn.flush()
+}
+
+// Close flushes any buffered metrics, and closes the connection shared by c
+// and every client cloned from it.
+func (c *Client) Close() {
+	c.conn.close()
+}
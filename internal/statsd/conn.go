@@ -0,0 +1,210 @@
+package statsd
+
+import (
+	"net"
+	"sync"
+	"time"
+)
+
+const (
+	dialTimeout       = 1 * time.Second
+	writeTimeout      = 1 * time.Second
+	minRedialInterval = 1 * time.Second
+	maxRedialInterval = 1 * time.Minute
+)
+
+// conn buffers metrics into packets of up to maxPacketSize, and writes them
+// to the target when full, or every flush period.  The connection is made
+// lazily, and is remade after a failed write on anything except UDP, where
+// errors are usually transient.  While there is no connection, redials are
+// backed off, and packets which can't be sent are dropped, or written to the
+// spool if there is one.  Once anything is spooled, everything goes through
+// the spool until it is empty, so metrics are always sent in order.  Writes
+// are made under the lock, so they time out, and a peer which stops reading
+// is treated as a failed write rather than blocking every caller.
+type conn struct {
+	network       string
+	address       string
+	maxPacketSize int
+	timestamps    bool
+	errorHandler  func(error)
+
+	lock           sync.Mutex
+	buf            []byte
+	stamps         []int64
+	netConn        net.Conn
+	nextDial       time.Time
+	redialInterval time.Duration
+	spool          *spool
+
+	chStop chan struct{}
+	wg     sync.WaitGroup
+}
+
+func newConn(cfg *Config, sp *spool) *conn {
+	c := &conn{
+		network:        cfg.Network,
+		address:        cfg.Address,
+		maxPacketSize:  cfg.MaxPacketSize,
+		timestamps:     cfg.TagFormat == Datadog,
+		errorHandler:   cfg.ErrorHandler,
+		spool:          sp,
+		buf:            make([]byte, 0, cfg.MaxPacketSize),
+		redialInterval: minRedialInterval,
+		chStop:         make(chan struct{}),
+	}
+	c.wg.Add(1)
+	go c.flushLoop(cfg.FlushPeriod)
+	return c
+}
+
+func (c *conn) flushLoop(flushPeriod time.Duration) {
+	defer c.wg.Done()
+	tckr := time.NewTicker(flushPeriod)
+	defer tckr.Stop()
+	for {
+		select {
+		case <-tckr.C:
+			c.flush()
+		case <-c.chStop:
+			return
+		}
+	}
+}
+
+func (c *conn) handleError(err error) {
+	if c.errorHandler != nil {
+		c.errorHandler(err)
+	}
+}
+
+// write buffers line, which was sampled at timestamp, in unix seconds.  A
+// zero timestamp is the current time.
+func (c *conn) write(line []byte, timestamp int64) {
+	if timestamp == 0 {
+		timestamp = time.Now().Unix()
+	}
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if len(c.buf) > 0 && len(c.buf)+1+len(line) > c.maxPacketSize {
+		c.flushLocked()
+	}
+	if len(c.buf) > 0 {
+		c.buf = append(c.buf, '\n')
+	}
+	c.buf = append(c.buf, line...)
+	c.stamps = append(c.stamps, timestamp)
+}
+
+func (c *conn) flush() {
+	c.lock.Lock()
+	c.flushLocked()
+	c.lock.Unlock()
+}
+
+func (c *conn) dialLocked() bool {
+	now := time.Now()
+	if now.Before(c.nextDial) {
+		return false
+	}
+	netConn, err := net.DialTimeout(c.network, c.address, dialTimeout)
+	if err != nil {
+		c.handleError(err)
+		c.nextDial = now.Add(c.redialInterval)
+		if c.redialInterval *= 2; c.redialInterval > maxRedialInterval {
+			c.redialInterval = maxRedialInterval
+		}
+		return false
+	}
+	c.netConn = netConn
+	c.redialInterval = minRedialInterval
+	return true
+}
+
+func (c *conn) spoolLocked() {
+	if c.spool == nil {
+		return
+	}
+	if err := c.spool.append(c.buf, c.stamps); err != nil {
+		c.handleError(err)
+	}
+}
+
+// writeLocked writes data to the connection, and returns how much of it was
+// written, which may be some of it if the write failed.
+func (c *conn) writeLocked(data []byte) (int, error) {
+	var n int
+	err := c.netConn.SetWriteDeadline(time.Now().Add(writeTimeout))
+	if err == nil {
+		n, err = c.netConn.Write(data)
+	}
+	if err != nil {
+		c.handleError(err)
+		if c.network != "udp" && c.network != "udp4" && c.network != "udp6" {
+			_ = c.netConn.Close()
+			c.netConn = nil
+		}
+	}
+	return n, err
+}
+
+func (c *conn) flushLocked() {
+	if len(c.buf) == 0 && (c.spool == nil || c.spool.empty()) {
+		return
+	}
+	defer func() {
+		c.buf = c.buf[:0]
+		c.stamps = c.stamps[:0]
+	}()
+
+	if c.spool != nil && !c.spool.empty() {
+		c.spoolLocked()
+		if c.netConn == nil && !c.dialLocked() {
+			return
+		}
+		if err := c.spool.replay(c.timestamps, c.writeLocked); err != nil && c.netConn != nil {
+			// A write error has already been reported, so this is the spool.
+			c.handleError(err)
+		}
+		return
+	}
+
+	if c.netConn == nil && !c.dialLocked() {
+		c.spoolLocked()
+		return
+	}
+	if IsStream(c.network) {
+		c.buf = append(c.buf, '\n')
+	}
+	if _, err := c.writeLocked(c.buf); err != nil {
+		if IsStream(c.network) {
+			c.buf = c.buf[:len(c.buf)-1]
+		}
+		c.spoolLocked()
+	}
+}
+
+func (c *conn) spoolStats() SpoolStats {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if c.spool == nil {
+		return SpoolStats{}
+	}
+	return c.spool.readStats()
+}
+
+func (c *conn) close() {
+	close(c.chStop)
+	c.wg.Wait()
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.flushLocked()
+	if c.netConn != nil {
+		_ = c.netConn.Close()
+		c.netConn = nil
+	}
+	if c.spool != nil {
+		c.spool.closeCurrent()
+	}
+}
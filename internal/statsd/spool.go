@@ -0,0 +1,247 @@
+package statsd
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const spoolSuffix = ".spool"
+
+// SpoolStats are the counters of a spool since they were last read.
+type SpoolStats struct {
+	Spooled  int64
+	Replayed int64
+	Dropped  int64
+	Bytes    int64
+}
+
+// spool is a write-ahead buffer of metrics on disk, for when the target is
+// unreachable.  It is a directory of segment files, named by creation time,
+// each containing lines of "timestamp metric".  Metrics are appended to the
+// newest segment, and replayed a segment at a time from the oldest.  Once the
+// spool is over its maximum size, the oldest segments are dropped, and
+// metrics older than the maximum age are dropped when they are replayed.
+type spool struct {
+	directory   string
+	maxSize     int64
+	maxAge      time.Duration
+	segmentSize int64
+
+	segments []*segment
+	current  *os.File
+	size     int64
+
+	stats SpoolStats
+}
+
+type segment struct {
+	path   string
+	size   int64
+	lines  int64 // not yet replayed
+	offset int64 // of the first line not yet replayed
+}
+
+func openSpool(opts *SpoolOpts) (*spool, error) {
+	if err := os.MkdirAll(opts.Directory, 0700); err != nil {
+		return nil, err
+	}
+	s := &spool{
+		directory:   opts.Directory,
+		maxSize:     opts.MaxSize,
+		maxAge:      opts.MaxAge,
+		segmentSize: opts.MaxSize / 16,
+	}
+
+	// Pick up anything left by a previous run.
+	entries, err := ioutil.ReadDir(opts.Directory)
+	if err != nil {
+		return nil, err
+	}
+	for _, entry := range entries {
+		if !entry.Mode().IsRegular() || !strings.HasSuffix(entry.Name(), spoolSuffix) {
+			continue
+		}
+		path := filepath.Join(opts.Directory, entry.Name())
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		s.segments = append(s.segments, &segment{
+			path:  path,
+			size:  entry.Size(),
+			lines: int64(bytes.Count(data, []byte{'\n'})),
+		})
+		s.size += entry.Size()
+	}
+	sort.Slice(s.segments, func(i, j int) bool {
+		return s.segments[i].path < s.segments[j].path
+	})
+	return s, nil
+}
+
+func (s *spool) empty() bool {
+	return len(s.segments) == 0
+}
+
+func (s *spool) readStats() SpoolStats {
+	stats := s.stats
+	stats.Bytes = s.size
+	s.stats = SpoolStats{}
+	return stats
+}
+
+func (s *spool) closeCurrent() {
+	if s.current != nil {
+		_ = s.current.Close()
+		s.current = nil
+	}
+}
+
+// append writes the newline separated metrics in buf to the spool, with the
+// matching timestamps in stamps.
+func (s *spool) append(buf []byte, stamps []int64) error {
+	if len(buf) == 0 {
+		return nil
+	}
+
+	if s.current == nil || s.segments[len(s.segments)-1].size >= s.segmentSize {
+		s.closeCurrent()
+		path := filepath.Join(s.directory, fmt.Sprintf("%020d%s", time.Now().UnixNano(), spoolSuffix))
+		f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0600)
+		if err != nil {
+			s.stats.Dropped += int64(len(stamps))
+			return err
+		}
+		s.current = f
+		s.segments = append(s.segments, &segment{path: path})
+	}
+
+	var out []byte
+	for idx, line := range bytes.Split(buf, []byte{'\n'}) {
+		out = strconv.AppendInt(out, stamps[idx], 10)
+		out = append(out, ' ')
+		out = append(out, line...)
+		out = append(out, '\n')
+	}
+	seg := s.segments[len(s.segments)-1]
+	n, err := s.current.Write(out)
+	seg.size += int64(n)
+	s.size += int64(n)
+	if err != nil {
+		s.stats.Dropped += int64(len(stamps))
+		s.closeCurrent()
+		return err
+	}
+	seg.lines += int64(len(stamps))
+	s.stats.Spooled += int64(len(stamps))
+
+	for s.size > s.maxSize && len(s.segments) > 1 {
+		s.removeOldest()
+		s.stats.Dropped += s.segments[0].lines
+		s.segments = s.segments[1:]
+	}
+	return nil
+}
+
+func (s *spool) removeOldest() {
+	oldest := s.segments[0]
+	_ = os.Remove(oldest.path)
+	s.size -= oldest.size
+}
+
+// replay reads the oldest segment and passes it to write, formatted for the
+// target, with Datadog timestamps if timestamps is set.  The segment is only
+// removed once write has taken all of it.  After a partial write, the lines
+// which were written in full are consumed, and the next replay resumes from
+// the first one which wasn't.
+func (s *spool) replay(timestamps bool, write func(data []byte) (int, error)) error {
+	if len(s.segments) == 1 {
+		// Don't replay a segment while it's being appended to.
+		s.closeCurrent()
+	}
+	oldest := s.segments[0]
+
+	f, err := os.Open(oldest.path)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = f.Close()
+	}()
+	if _, err := f.Seek(oldest.offset, io.SeekStart); err != nil {
+		return err
+	}
+
+	// Each line read, with where it ends in out and in the segment, so a
+	// partial write can be mapped back to the segment.
+	type replayLine struct {
+		outEnd   int
+		fileEnd  int64
+		replayed bool
+	}
+	var lines []replayLine
+
+	cutoff := time.Now().Add(-s.maxAge).Unix()
+	var out []byte
+	fileEnd := oldest.offset
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		fileEnd += int64(len(line)) + 1
+		space := bytes.IndexByte(line, ' ')
+		if space == -1 {
+			lines = append(lines, replayLine{outEnd: len(out), fileEnd: fileEnd})
+			continue
+		}
+		ts, err := strconv.ParseInt(string(line[:space]), 10, 64)
+		if err != nil || ts < cutoff {
+			lines = append(lines, replayLine{outEnd: len(out), fileEnd: fileEnd})
+			continue
+		}
+		out = append(out, line[space+1:]...)
+		if timestamps {
+			out = append(out, "|T"...)
+			out = strconv.AppendInt(out, ts, 10)
+		}
+		out = append(out, '\n')
+		lines = append(lines, replayLine{outEnd: len(out), fileEnd: fileEnd, replayed: true})
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+
+	var written int
+	var writeErr error
+	if len(out) > 0 {
+		written, writeErr = write(out)
+	}
+
+	for _, line := range lines {
+		if line.outEnd > written {
+			break
+		}
+		oldest.offset = line.fileEnd
+		oldest.lines--
+		if line.replayed {
+			s.stats.Replayed++
+		} else {
+			s.stats.Dropped++
+		}
+	}
+	if writeErr != nil {
+		return writeErr
+	}
+
+	s.removeOldest()
+	s.segments = s.segments[1:]
+	return nil
+}
@@ -0,0 +1,55 @@
+package statsd
+
+import (
+	"fmt"
+	"net"
+	"strings"
+)
+
+const defaultPort = "8125"
+
+// ParseTarget parses a target address into a network and address for
+// net.Dial.  A target without a scheme is a UDP over IPv4 host, with an
+// optional port.  Otherwise the scheme is one of:
+//
+//	udp://host[:port], udp4://, udp6://
+//	tcp://host[:port], tcp4://, tcp6://
+//	unix:///path/to/socket        (datagram, as used by DogStatsD)
+//	unixstream:///path/to/socket
+func ParseTarget(target string) (string, string, error) {
+	network := "udp4"
+	address := target
+	if idx := strings.Index(target, "://"); idx != -1 {
+		address = target[idx+3:]
+		switch scheme := target[:idx]; scheme {
+		case "udp", "udp4", "udp6", "tcp", "tcp4", "tcp6":
+			network = scheme
+		case "unix":
+			network = "unixgram"
+		case "unixstream":
+			network = "unix"
+		default:
+			return "", "", fmt.Errorf("unknown scheme %s in target %s", scheme, target)
+		}
+	}
+
+	if address == "" {
+		return "", "", fmt.Errorf("missing address in target %s", target)
+	}
+
+	if network == "unix" || network == "unixgram" {
+		return network, address, nil
+	}
+
+	if _, _, err := net.SplitHostPort(address); err != nil {
+		host := strings.TrimSuffix(strings.TrimPrefix(address, "["), "]")
+		address = net.JoinHostPort(host, defaultPort)
+	}
+	return network, address, nil
+}
+
+// IsStream returns true for the stream networks, which need a newline after
+// the last metric in a write, and can be spooled.
+func IsStream(network string) bool {
+	return network == "tcp" || network == "tcp4" || network == "tcp6" || network == "unix"
+}
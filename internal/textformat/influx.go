@@ -0,0 +1,153 @@
+package textformat
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ParseInflux parses Influx line protocol:
+//
+//	measurement[,tag=value...] field=value[,field=value...] [timestamp]
+//
+// Each numeric or boolean field becomes a sample named measurement.field, or
+// just measurement for a field named "value".  String fields are ignored.
+func ParseInflux(data []byte) ([]Sample, []error) {
+	var samples []Sample
+	var errs []error
+	for idx, line := range bytes.Split(data, []byte{'\n'}) {
+		line = bytes.TrimSpace(line)
+		if len(line) == 0 || line[0] == '#' {
+			continue
+		}
+		parsed, err := parseInfluxLine(string(line))
+		if err != nil {
+			errs = append(errs, &LineError{Line: idx + 1, Text: string(line), Err: err})
+			continue
+		}
+		samples = append(samples, parsed...)
+	}
+	return samples, errs
+}
+
+// splitInflux splits s on sep, except where sep is escaped with a backslash,
+// or inside a double quoted string.  Escapes are left in place.
+func splitInflux(s string, sep byte) []string {
+	var parts []string
+	inQuote := false
+	start := 0
+	for i := 0; i < len(s); i++ {
+		switch ch := s[i]; {
+		case ch == '\\':
+			i++
+		case ch == '"':
+			inQuote = !inQuote
+		case ch == sep && !inQuote:
+			parts = append(parts, s[start:i])
+			start = i + 1
+		}
+	}
+	return append(parts, s[start:])
+}
+
+func unescapeInflux(s string) string {
+	if strings.IndexByte(s, '\\') == -1 {
+		return s
+	}
+	sb := strings.Builder{}
+	for i := 0; i < len(s); i++ {
+		if s[i] == '\\' && i+1 < len(s) {
+			i++
+		}
+		sb.WriteByte(s[i])
+	}
+	return sb.String()
+}
+
+// splitKeyValue splits an unescaped "key=value" pair.
+func splitKeyValue(s string) (string, string, error) {
+	kv := splitInflux(s, '=')
+	if len(kv) != 2 || kv[0] == "" {
+		return "", "", fmt.Errorf("invalid key=value %s", s)
+	}
+	return unescapeInflux(kv[0]), kv[1], nil
+}
+
+func parseInfluxValue(s string) (float64, bool, error) {
+	switch s {
+	case "t", "T", "true", "True", "TRUE":
+		return 1, true, nil
+	case "f", "F", "false", "False", "FALSE":
+		return 0, true, nil
+	}
+	if strings.HasPrefix(s, "\"") {
+		return 0, false, nil // string field
+	}
+	if strings.HasSuffix(s, "i") || strings.HasSuffix(s, "u") {
+		s = s[:len(s)-1]
+	}
+	v, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return 0, false, fmt.Errorf("invalid value %s", s)
+	}
+	return v, true, nil
+}
+
+func parseInfluxLine(line string) ([]Sample, error) {
+	sections := splitInflux(line, ' ')
+	if len(sections) < 2 || len(sections) > 3 {
+		return nil, fmt.Errorf("expected measurement, fields, and optional timestamp")
+	}
+
+	series := splitInflux(sections[0], ',')
+	measurement := unescapeInflux(series[0])
+	if measurement == "" {
+		return nil, fmt.Errorf("missing measurement")
+	}
+	var tags []string
+	for _, tag := range series[1:] {
+		k, v, err := splitKeyValue(tag)
+		if err != nil {
+			return nil, err
+		}
+		tags = append(tags, k, unescapeInflux(v))
+	}
+
+	var timestamp time.Time
+	if len(sections) == 3 {
+		ns, err := strconv.ParseInt(sections[2], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid timestamp %s", sections[2])
+		}
+		timestamp = time.Unix(0, ns)
+	}
+
+	var samples []Sample
+	for _, field := range splitInflux(sections[1], ',') {
+		k, v, err := splitKeyValue(field)
+		if err != nil {
+			return nil, err
+		}
+		value, ok, err := parseInfluxValue(v)
+		if err != nil {
+			return nil, err
+		} else if !ok {
+			continue
+		}
+		name := measurement + "." + k
+		if k == "value" {
+			name = measurement
+		}
+		samples = append(samples, Sample{
+			Name:       name,
+			Kind:       KindUntyped,
+			Value:      value,
+			Tags:       tags,
+			SampleRate: 1,
+			Timestamp:  timestamp,
+		})
+	}
+	return samples, nil
+}
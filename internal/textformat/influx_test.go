@@ -0,0 +1,67 @@
+package textformat
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestParseInflux(t *testing.T) {
+	tests := []struct {
+		input   string
+		samples []Sample
+		errs    int
+	}{
+		{
+			input:   "cpu value=1",
+			samples: []Sample{{Name: "cpu", Kind: KindUntyped, Value: 1, SampleRate: 1}},
+		},
+		{
+			input: "cpu,host=a,core=0 user=1,system=2i,idle=3u 1600000000000000000",
+			samples: []Sample{
+				{Name: "cpu.user", Kind: KindUntyped, Value: 1, Tags: []string{"host", "a", "core", "0"}, SampleRate: 1, Timestamp: time.Unix(1600000000, 0)},
+				{Name: "cpu.system", Kind: KindUntyped, Value: 2, Tags: []string{"host", "a", "core", "0"}, SampleRate: 1, Timestamp: time.Unix(1600000000, 0)},
+				{Name: "cpu.idle", Kind: KindUntyped, Value: 3, Tags: []string{"host", "a", "core", "0"}, SampleRate: 1, Timestamp: time.Unix(1600000000, 0)},
+			},
+		},
+		{
+			input: "up ok=t,down=false,name=\"a b, c=d\"",
+			samples: []Sample{
+				{Name: "up.ok", Kind: KindUntyped, Value: 1, SampleRate: 1},
+				{Name: "up.down", Kind: KindUntyped, Value: 0, SampleRate: 1},
+			},
+		},
+		{
+			input:   `disk\ io,path=/a\,b\ c value=1`,
+			samples: []Sample{{Name: "disk io", Kind: KindUntyped, Value: 1, Tags: []string{"path", "/a,b c"}, SampleRate: 1}},
+		},
+		{
+			input:   "# comment\n\n  m value=1  ",
+			samples: []Sample{{Name: "m", Kind: KindUntyped, Value: 1, SampleRate: 1}},
+		},
+
+		// malformed lines are skipped, and don't lose the rest
+		{input: "m", errs: 1},
+		{input: "m value=1 1 extra", errs: 1},
+		{input: ",host=a value=1", errs: 1},
+		{input: "m,host value=1", errs: 1},
+		{input: "m value", errs: 1},
+		{input: "m =1", errs: 1},
+		{input: "m value=x", errs: 1},
+		{input: "m value=1 x", errs: 1},
+		{
+			input:   "m value=x\nn value=1",
+			samples: []Sample{{Name: "n", Kind: KindUntyped, Value: 1, SampleRate: 1}},
+			errs:    1,
+		},
+	}
+	for _, test := range tests {
+		samples, errs := ParseInflux([]byte(test.input))
+		if len(errs) != test.errs {
+			t.Errorf("%q: got errors %v, expected %d", test.input, errs, test.errs)
+		}
+		if !reflect.DeepEqual(samples, test.samples) && len(samples)+len(test.samples) != 0 {
+			t.Errorf("%q: got %+v, expected %+v", test.input, samples, test.samples)
+		}
+	}
+}
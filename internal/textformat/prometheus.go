@@ -0,0 +1,171 @@
+package textformat
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ParsePrometheus parses the Prometheus text exposition format.  Samples take
+// their Kind from the # TYPE of their family, so the _bucket, _sum and _count
+// series of a histogram are all KindHistogram.
+func ParsePrometheus(data []byte) ([]Sample, []error) {
+	var samples []Sample
+	var errs []error
+	types := make(map[string]Kind)
+	for idx, line := range bytes.Split(data, []byte{'\n'}) {
+		line = bytes.TrimSpace(line)
+		if len(line) == 0 {
+			continue
+		}
+		if line[0] == '#' {
+			if err := parsePrometheusComment(string(line), types); err != nil {
+				errs = append(errs, &LineError{Line: idx + 1, Text: string(line), Err: err})
+			}
+			continue
+		}
+		s, err := parsePrometheusSample(string(line))
+		if err != nil {
+			errs = append(errs, &LineError{Line: idx + 1, Text: string(line), Err: err})
+			continue
+		}
+		s.Kind = prometheusKind(s.Name, types)
+		samples = append(samples, s)
+	}
+	return samples, errs
+}
+
+func parsePrometheusComment(line string, types map[string]Kind) error {
+	fields := strings.Fields(line[1:])
+	if len(fields) < 1 || fields[0] != "TYPE" {
+		return nil // HELP, or a plain comment
+	}
+	if len(fields) != 3 {
+		return fmt.Errorf("malformed TYPE")
+	}
+	switch fields[2] {
+	case "counter":
+		types[fields[1]] = KindCounter
+	case "gauge":
+		types[fields[1]] = KindGauge
+	case "histogram":
+		types[fields[1]] = KindHistogram
+	case "summary":
+		types[fields[1]] = KindSummary
+	case "untyped":
+		types[fields[1]] = KindUntyped
+	default:
+		return fmt.Errorf("unknown type %s", fields[2])
+	}
+	return nil
+}
+
+func prometheusKind(name string, types map[string]Kind) Kind {
+	if kind, ok := types[name]; ok {
+		return kind
+	}
+	for _, suffix := range []string{"_bucket", "_sum", "_count"} {
+		if strings.HasSuffix(name, suffix) {
+			kind := types[strings.TrimSuffix(name, suffix)]
+			if kind == KindHistogram || (kind == KindSummary && suffix != "_bucket") {
+				return kind
+			}
+		}
+	}
+	return KindUntyped
+}
+
+func parsePrometheusValue(s string) (float64, error) {
+	switch s {
+	case "NaN":
+		return math.NaN(), nil
+	case "+Inf", "Inf":
+		return math.Inf(1), nil
+	case "-Inf":
+		return math.Inf(-1), nil
+	}
+	return strconv.ParseFloat(s, 64)
+}
+
+// parsePrometheusLabels parses the labels starting after the opening '{', and
+// returns them with the remainder of the line after the closing '}'.
+func parsePrometheusLabels(s string) ([]string, string, error) {
+	var tags []string
+	for {
+		s = strings.TrimLeft(s, " \t")
+		if strings.HasPrefix(s, "}") {
+			return tags, s[1:], nil
+		}
+		eq := strings.IndexByte(s, '=')
+		if eq <= 0 {
+			return nil, "", fmt.Errorf("expected label name")
+		}
+		name := strings.TrimSpace(s[:eq])
+		s = strings.TrimLeft(s[eq+1:], " \t")
+		if !strings.HasPrefix(s, "\"") {
+			return nil, "", fmt.Errorf("expected quoted value for label %s", name)
+		}
+
+		sb := strings.Builder{}
+		i := 1
+		for ; i < len(s) && s[i] != '"'; i++ {
+			if s[i] == '\\' && i+1 < len(s) {
+				i++
+				if s[i] == 'n' {
+					sb.WriteByte('\n')
+					continue
+				}
+			}
+			sb.WriteByte(s[i])
+		}
+		if i >= len(s) {
+			return nil, "", fmt.Errorf("unterminated value for label %s", name)
+		}
+		tags = append(tags, name, sb.String())
+
+		s = strings.TrimLeft(s[i+1:], " \t")
+		if strings.HasPrefix(s, ",") {
+			s = s[1:]
+		} else if !strings.HasPrefix(s, "}") {
+			return nil, "", fmt.Errorf("expected ',' or '}' after label %s", name)
+		}
+	}
+}
+
+func parsePrometheusSample(line string) (Sample, error) {
+	s := Sample{
+		SampleRate: 1,
+	}
+	end := strings.IndexAny(line, "{ \t")
+	if end == -1 {
+		return s, fmt.Errorf("missing value")
+	}
+	s.Name = line[:end]
+	rest := line[end:]
+	if strings.HasPrefix(rest, "{") {
+		var err error
+		if s.Tags, rest, err = parsePrometheusLabels(rest[1:]); err != nil {
+			return s, err
+		}
+	}
+
+	fields := strings.Fields(rest)
+	if len(fields) < 1 || len(fields) > 2 {
+		return s, fmt.Errorf("expected value and optional timestamp")
+	}
+	var err error
+	if s.Value, err = parsePrometheusValue(fields[0]); err != nil {
+		return s, fmt.Errorf("invalid value %s", fields[0])
+	}
+	if len(fields) == 2 {
+		ms, err := strconv.ParseInt(fields[1], 10, 64)
+		if err != nil {
+			return s, fmt.Errorf("invalid timestamp %s", fields[1])
+		}
+		s.Timestamp = time.Unix(0, ms*int64(time.Millisecond))
+	}
+	return s, nil
+}
@@ -0,0 +1,77 @@
+package textformat
+
+import (
+	"math"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestParsePrometheus(t *testing.T) {
+	tests := []struct {
+		input   string
+		samples []Sample
+		errs    int
+	}{
+		{
+			input:   "m 1",
+			samples: []Sample{{Name: "m", Kind: KindUntyped, Value: 1, SampleRate: 1}},
+		},
+		{
+			input: "# HELP requests_total Requests.\n# TYPE requests_total counter\nrequests_total{code=\"200\",method=\"get\"} 10 1600000000000\n# TYPE temp gauge\ntemp 1.5e1",
+			samples: []Sample{
+				{Name: "requests_total", Kind: KindCounter, Value: 10, Tags: []string{"code", "200", "method", "get"}, SampleRate: 1, Timestamp: time.Unix(1600000000, 0)},
+				{Name: "temp", Kind: KindGauge, Value: 15, SampleRate: 1},
+			},
+		},
+		{
+			input: "# TYPE h histogram\nh_bucket{le=\"+Inf\"} 3\nh_sum 4\nh_count 3\n# TYPE s summary\ns{quantile=\"0.5\"} 1\ns_sum 2\ns_count 1\ns_bucket 1",
+			samples: []Sample{
+				{Name: "h_bucket", Kind: KindHistogram, Value: 3, Tags: []string{"le", "+Inf"}, SampleRate: 1},
+				{Name: "h_sum", Kind: KindHistogram, Value: 4, SampleRate: 1},
+				{Name: "h_count", Kind: KindHistogram, Value: 3, SampleRate: 1},
+				{Name: "s", Kind: KindSummary, Value: 1, Tags: []string{"quantile", "0.5"}, SampleRate: 1},
+				{Name: "s_sum", Kind: KindSummary, Value: 2, SampleRate: 1},
+				{Name: "s_count", Kind: KindSummary, Value: 1, SampleRate: 1},
+				{Name: "s_bucket", Kind: KindUntyped, Value: 1, SampleRate: 1},
+			},
+		},
+		{
+			input:   `m{ a = "x \"y\"\\\nz" , b="" , } -Inf`,
+			samples: []Sample{{Name: "m", Kind: KindUntyped, Value: math.Inf(-1), Tags: []string{"a", "x \"y\"\\\nz", "b", ""}, SampleRate: 1}},
+		},
+
+		// malformed lines are skipped, and don't lose the rest
+		{input: "m", errs: 1},
+		{input: "m x", errs: 1},
+		{input: "m 1 x", errs: 1},
+		{input: "m 1 2 3", errs: 1},
+		{input: "m{a} 1", errs: 1},
+		{input: "m{a=x} 1", errs: 1},
+		{input: "m{a=\"x} 1", errs: 1},
+		{input: "m{a=\"x\" b=\"y\"} 1", errs: 1},
+		{input: "# TYPE m", errs: 1},
+		{input: "# TYPE m info", errs: 1},
+		{
+			input:   "m x\nn 1",
+			samples: []Sample{{Name: "n", Kind: KindUntyped, Value: 1, SampleRate: 1}},
+			errs:    1,
+		},
+	}
+	for _, test := range tests {
+		samples, errs := ParsePrometheus([]byte(test.input))
+		if len(errs) != test.errs {
+			t.Errorf("%q: got errors %v, expected %d", test.input, errs, test.errs)
+		}
+		if !reflect.DeepEqual(samples, test.samples) && len(samples)+len(test.samples) != 0 {
+			t.Errorf("%q: got %+v, expected %+v", test.input, samples, test.samples)
+		}
+	}
+}
+
+func TestParsePrometheusNaN(t *testing.T) {
+	samples, errs := ParsePrometheus([]byte("m NaN"))
+	if len(errs) != 0 || len(samples) != 1 || !math.IsNaN(samples[0].Value) {
+		t.Errorf("got %+v %v, expected a single NaN", samples, errs)
+	}
+}
@@ -0,0 +1,88 @@
+package textformat
+
+import (
+	"fmt"
+	"time"
+)
+
+// Kind is the type of a sample as declared by its source format.  Formats
+// without types, such as Influx line protocol, produce KindUntyped.
+type Kind string
+
+const (
+	KindUntyped      = Kind("untyped")
+	KindGauge        = Kind("gauge")
+	KindCounter      = Kind("counter")
+	KindTiming       = Kind("timing")
+	KindHistogram    = Kind("histogram")
+	KindDistribution = Kind("distribution")
+	KindSet          = Kind("set")
+	KindSummary      = Kind("summary")
+)
+
+// Sample is a single value parsed from a text format.  Tags are key/value
+// pairs, in the form accepted by statser.Pool.
+type Sample struct {
+	Name  string
+	Kind  Kind
+	Value float64
+	Tags  []string
+
+	// SampleRate is the statsd sample rate, and is 1 for everything else.
+	SampleRate float64
+
+	// Relative is set for statsd gauges with an explicit sign, which adjust
+	// the previous value rather than replacing it.
+	Relative bool
+
+	// SetValue is the member of a statsd set, as they need not be numeric.
+	SetValue string
+
+	// Timestamp is zero unless the source provided one.
+	Timestamp time.Time
+}
+
+// LineError is returned for each line which could not be parsed.  Parsers
+// skip bad lines rather than failing, so a single bad line doesn't lose the
+// remainder of the input.
+type LineError struct {
+	Line int
+	Text string
+	Err  error
+}
+
+func (le *LineError) Error() string {
+	return fmt.Sprintf("line %d: %v: %q", le.Line, le.Err, le.Text)
+}
+
+// Format is a supported text format.
+type Format string
+
+const (
+	FormatStatsd     = Format("statsd")
+	FormatInflux     = Format("influx")
+	FormatPrometheus = Format("prometheus")
+)
+
+func ParseFormat(s string) (Format, error) {
+	switch f := Format(s); f {
+	case FormatStatsd, FormatInflux, FormatPrometheus:
+		return f, nil
+	default:
+		return "", fmt.Errorf("unknown format %s, expected statsd, influx, or prometheus", s)
+	}
+}
+
+// Parse parses data in the given format.
+func Parse(format Format, data []byte) ([]Sample, []error) {
+	switch format {
+	case FormatStatsd:
+		return ParseStatsd(data)
+	case FormatInflux:
+		return ParseInflux(data)
+	case FormatPrometheus:
+		return ParsePrometheus(data)
+	default:
+		return nil, []error{fmt.Errorf("unknown format %s", format)}
+	}
+}
@@ -0,0 +1,127 @@
+package textformat
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ParseStatsd parses statsd lines, including the DogStatsD extensions:
+//
+//	name:value[:value...]|type[|@rate][|#tag:value,tag...][|Ttimestamp]
+//
+// Events and service checks are ignored, as are unknown extension fields.
+func ParseStatsd(data []byte) ([]Sample, []error) {
+	var samples []Sample
+	var errs []error
+	for idx, line := range bytes.Split(data, []byte{'\n'}) {
+		line = bytes.TrimSpace(line)
+		if len(line) == 0 || bytes.HasPrefix(line, []byte("_e{")) || bytes.HasPrefix(line, []byte("_sc|")) {
+			continue
+		}
+		parsed, err := parseStatsdLine(string(line))
+		if err != nil {
+			errs = append(errs, &LineError{Line: idx + 1, Text: string(line), Err: err})
+			continue
+		}
+		samples = append(samples, parsed...)
+	}
+	return samples, errs
+}
+
+func statsdKind(t string) (Kind, error) {
+	switch t {
+	case "c":
+		return KindCounter, nil
+	case "g":
+		return KindGauge, nil
+	case "ms":
+		return KindTiming, nil
+	case "h":
+		return KindHistogram, nil
+	case "d":
+		return KindDistribution, nil
+	case "s":
+		return KindSet, nil
+	default:
+		return "", fmt.Errorf("unknown type %s", t)
+	}
+}
+
+func parseStatsdTags(s string) []string {
+	var tags []string
+	for _, tag := range strings.Split(s, ",") {
+		if tag == "" {
+			continue
+		}
+		if colon := strings.IndexByte(tag, ':'); colon != -1 {
+			tags = append(tags, tag[:colon], tag[colon+1:])
+		} else {
+			tags = append(tags, tag, "")
+		}
+	}
+	return tags
+}
+
+func parseStatsdLine(line string) ([]Sample, error) {
+	fields := strings.Split(line, "|")
+	if len(fields) < 2 {
+		return nil, fmt.Errorf("missing type")
+	}
+	colon := strings.IndexByte(fields[0], ':')
+	if colon <= 0 {
+		return nil, fmt.Errorf("missing name or value")
+	}
+	name := fields[0][:colon]
+	values := strings.Split(fields[0][colon+1:], ":")
+
+	kind, err := statsdKind(fields[1])
+	if err != nil {
+		return nil, err
+	}
+
+	proto := Sample{
+		Name:       name,
+		Kind:       kind,
+		SampleRate: 1,
+	}
+	for _, field := range fields[2:] {
+		switch {
+		case strings.HasPrefix(field, "@"):
+			rate, err := strconv.ParseFloat(field[1:], 64)
+			if err != nil || rate <= 0 || rate > 1 {
+				return nil, fmt.Errorf("invalid sample rate %s", field[1:])
+			}
+			proto.SampleRate = rate
+		case strings.HasPrefix(field, "#"):
+			proto.Tags = parseStatsdTags(field[1:])
+		case strings.HasPrefix(field, "T"):
+			ts, err := strconv.ParseInt(field[1:], 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid timestamp %s", field[1:])
+			}
+			proto.Timestamp = time.Unix(ts, 0)
+		}
+	}
+
+	samples := make([]Sample, 0, len(values))
+	for _, value := range values {
+		s := proto
+		if kind == KindSet {
+			s.SetValue = value
+			samples = append(samples, s)
+			continue
+		}
+		if kind == KindGauge && (strings.HasPrefix(value, "+") || strings.HasPrefix(value, "-")) {
+			s.Relative = true
+		}
+		s.Value, err = strconv.ParseFloat(value, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid value %s", value)
+		}
+		samples = append(samples, s)
+	}
+	return samples, nil
+}
@@ -0,0 +1,93 @@
+package textformat
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestParseStatsd(t *testing.T) {
+	tests := []struct {
+		input   string
+		samples []Sample
+		errs    int
+	}{
+		{
+			input:   "a:1|c",
+			samples: []Sample{{Name: "a", Kind: KindCounter, Value: 1, SampleRate: 1}},
+		},
+		{
+			input: "a:1.5|g\nb:2|ms\nc:3|h\nd:4|d",
+			samples: []Sample{
+				{Name: "a", Kind: KindGauge, Value: 1.5, SampleRate: 1},
+				{Name: "b", Kind: KindTiming, Value: 2, SampleRate: 1},
+				{Name: "c", Kind: KindHistogram, Value: 3, SampleRate: 1},
+				{Name: "d", Kind: KindDistribution, Value: 4, SampleRate: 1},
+			},
+		},
+		{
+			input: "a:+1|g\nb:-2|g",
+			samples: []Sample{
+				{Name: "a", Kind: KindGauge, Value: 1, SampleRate: 1, Relative: true},
+				{Name: "b", Kind: KindGauge, Value: -2, SampleRate: 1, Relative: true},
+			},
+		},
+		{
+			input:   "a:x|s",
+			samples: []Sample{{Name: "a", Kind: KindSet, SampleRate: 1, SetValue: "x"}},
+		},
+		{
+			input:   "a:1|c|@0.5|#env:prod,canary|T1600000000",
+			samples: []Sample{{Name: "a", Kind: KindCounter, Value: 1, SampleRate: 0.5, Tags: []string{"env", "prod", "canary", ""}, Timestamp: time.Unix(1600000000, 0)}},
+		},
+		{
+			input: "a:1:2|ms",
+			samples: []Sample{
+				{Name: "a", Kind: KindTiming, Value: 1, SampleRate: 1},
+				{Name: "a", Kind: KindTiming, Value: 2, SampleRate: 1},
+			},
+		},
+		{
+			input:   "_e{5,4}:title|text\n_sc|name|0\n\n  a:1|c  ",
+			samples: []Sample{{Name: "a", Kind: KindCounter, Value: 1, SampleRate: 1}},
+		},
+
+		// malformed lines are skipped, and don't lose the rest
+		{input: "a", errs: 1},
+		{input: "a|c", errs: 1},
+		{input: ":1|c", errs: 1},
+		{input: "a:1|x", errs: 1},
+		{input: "a:x|c", errs: 1},
+		{input: "a:1|c|@0", errs: 1},
+		{input: "a:1|c|@2", errs: 1},
+		{input: "a:1|c|Tx", errs: 1},
+		{
+			input:   "a:x|c\nb:1|c",
+			samples: []Sample{{Name: "b", Kind: KindCounter, Value: 1, SampleRate: 1}},
+			errs:    1,
+		},
+	}
+	for _, test := range tests {
+		samples, errs := ParseStatsd([]byte(test.input))
+		if len(errs) != test.errs {
+			t.Errorf("%q: got errors %v, expected %d", test.input, errs, test.errs)
+		}
+		if !reflect.DeepEqual(samples, test.samples) && len(samples)+len(test.samples) != 0 {
+			t.Errorf("%q: got %+v, expected %+v", test.input, samples, test.samples)
+		}
+	}
+}
+
+func TestParseStatsdLineError(t *testing.T) {
+	_, errs := ParseStatsd([]byte("a:1|c\n\nb:x|c"))
+	if len(errs) != 1 {
+		t.Fatalf("got %d errors, expected 1", len(errs))
+	}
+	le, ok := errs[0].(*LineError)
+	if !ok {
+		t.Fatalf("got %T, expected *LineError", errs[0])
+	}
+	if le.Line != 3 || le.Text != "b:x|c" {
+		t.Errorf("got line %d %q, expected line 3 \"b:x|c\"", le.Line, le.Text)
+	}
+}
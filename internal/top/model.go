@@ -0,0 +1,165 @@
+// Package top keeps the latest samples from each emitter, and renders them as
+// a refreshing terminal view for the top mode.
+package top
+
+import (
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/squizzling/stats/internal/istats"
+)
+
+type series struct {
+	emitter string
+	name    string
+	tags    []string
+	label   string
+
+	// cumulative and unit are from the latest sample, as described by the
+	// emitter.
+	cumulative bool
+	unit       string
+
+	value    float64
+	rate     float64
+	hasRate  bool
+	lastTick time.Time
+}
+
+// Model is the latest state of every series, keyed by emitter, name and tags.
+type Model struct {
+	interval time.Duration
+	series   map[string]*series
+	lastTick time.Time
+}
+
+func NewModel(interval time.Duration) *Model {
+	return &Model{
+		interval: interval,
+		series:   make(map[string]*series),
+	}
+}
+
+// formatLabel formats a series as name{key=value,...}, without the host tag,
+// as every series in the view is from this host.
+func formatLabel(name string, tags []string) (string, []string) {
+	var kept []string
+	for i := 0; i+1 < len(tags); i += 2 {
+		if tags[i] != "host" {
+			kept = append(kept, tags[i], tags[i+1])
+		}
+	}
+	if len(kept) == 0 {
+		return name, nil
+	}
+	sb := strings.Builder{}
+	sb.WriteString(name)
+	sb.WriteByte('{')
+	for i := 0; i < len(kept); i += 2 {
+		if i != 0 {
+			sb.WriteByte(',')
+		}
+		sb.WriteString(kept[i])
+		sb.WriteByte('=')
+		sb.WriteString(kept[i+1])
+	}
+	sb.WriteByte('}')
+	return sb.String(), kept
+}
+
+// Update records the samples from an emitter for tick.  Gauges described as
+// cumulative counters become a rate against the previous tick, and counts are summed over the
+// tick and divided by the interval.
+func (m *Model) Update(emitter string, samples []istats.Sample, tick time.Time) {
+	m.lastTick = tick
+	for _, sample := range samples {
+		label, tags := formatLabel(sample.Name, sample.Tags)
+		key := emitter + "|" + label
+		s, ok := m.series[key]
+		if !ok {
+			s = &series{
+				emitter: emitter,
+				name:    sample.Name,
+				tags:    tags,
+				label:   label,
+			}
+			m.series[key] = s
+		}
+
+		s.cumulative = sample.Kind == istats.KindCumulative
+		s.unit = sample.Unit
+
+		switch sample.Kind {
+		case istats.KindCount:
+			if !s.lastTick.Equal(tick) {
+				s.value = 0
+			}
+			s.value += sample.Value
+			s.rate = s.value / m.interval.Seconds()
+			s.hasRate = true
+		case istats.KindCumulative:
+			if ok && !s.lastTick.Equal(tick) && sample.Value >= s.value {
+				s.rate = (sample.Value - s.value) / tick.Sub(s.lastTick).Seconds()
+				s.hasRate = true
+			}
+			s.value = sample.Value
+		default:
+			s.value = sample.Value
+		}
+		s.lastTick = tick
+	}
+}
+
+// Expire removes the series which weren't updated by the latest tick.
+func (m *Model) Expire() {
+	for key, s := range m.series {
+		if !s.lastTick.Equal(m.lastTick) {
+			delete(m.series, key)
+		}
+	}
+}
+
+// Emitters returns the names of the emitters with series, sorted.
+func (m *Model) Emitters() []string {
+	seen := make(map[string]struct{})
+	var emitters []string
+	for _, s := range m.series {
+		if _, ok := seen[s.emitter]; !ok {
+			seen[s.emitter] = struct{}{}
+			emitters = append(emitters, s.emitter)
+		}
+	}
+	sort.Strings(emitters)
+	return emitters
+}
+
+// sorted returns the series sorted by emitter, then label.
+func (m *Model) sorted() []*series {
+	out := make([]*series, 0, len(m.series))
+	for _, s := range m.series {
+		out = append(out, s)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].emitter != out[j].emitter {
+			return out[i].emitter < out[j].emitter
+		}
+		return out[i].label < out[j].label
+	})
+	return out
+}
+
+// cpuTotalRate returns the rate of the total for a procstat.cpu series, so
+// the other fields can be shown as a percentage of it.
+func (m *Model) cpuTotalRate(s *series) (float64, bool) {
+	dot := strings.LastIndexByte(s.name, '.')
+	if !strings.HasPrefix(s.name, "procstat.cpu.") || dot == -1 {
+		return 0, false
+	}
+	label, _ := formatLabel(s.name[:dot]+".total", s.tags)
+	total, ok := m.series[s.emitter+"|"+label]
+	if !ok || !total.hasRate || total.rate == 0 {
+		return 0, false
+	}
+	return total.rate, true
+}
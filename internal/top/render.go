@@ -0,0 +1,128 @@
+package top
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/squizzling/stats/pkg/collector"
+)
+
+// Filter restricts the series shown.  An empty Emitter shows every emitter,
+// and Text matches anywhere in the emitter name or series label.
+type Filter struct {
+	Emitter string
+	Text    string
+}
+
+func (f *Filter) match(s *series) bool {
+	if f.Emitter != "" && s.emitter != f.Emitter {
+		return false
+	}
+	if f.Text != "" && !strings.Contains(s.emitter, f.Text) && !strings.Contains(s.label, f.Text) {
+		return false
+	}
+	return true
+}
+
+func isBytes(s *series) bool {
+	return s.unit == string(collector.UnitBytes)
+}
+
+func formatBytes(v float64) string {
+	units := []string{"B", "KiB", "MiB", "GiB", "TiB", "PiB"}
+	idx := 0
+	for ; v >= 1024 && idx < len(units)-1; idx++ {
+		v /= 1024
+	}
+	return strconv.FormatFloat(v, 'f', 1, 64) + " " + units[idx]
+}
+
+func formatNumber(v float64) string {
+	if v == float64(int64(v)) {
+		return strconv.FormatInt(int64(v), 10)
+	}
+	return strconv.FormatFloat(v, 'f', 2, 64)
+}
+
+func (m *Model) format(s *series) string {
+	if s.cumulative || s.hasRate {
+		if !s.hasRate {
+			return "-"
+		}
+		if !strings.HasSuffix(s.name, ".total") {
+			if total, ok := m.cpuTotalRate(s); ok {
+				return strconv.FormatFloat(s.rate/total*100, 'f', 1, 64) + "%"
+			}
+		}
+		if isBytes(s) {
+			return formatBytes(s.rate) + "/s"
+		}
+		return formatNumber(s.rate) + "/s"
+	}
+	if isBytes(s) {
+		return formatBytes(s.value)
+	}
+	return formatNumber(s.value)
+}
+
+func truncate(s string, width int) string {
+	if len(s) > width {
+		return s[:width]
+	}
+	return s
+}
+
+// Render writes the view to w, clearing the screen first, limited to width
+// and height.  prompt is shown in place of the help line, when not empty.
+func (m *Model) Render(w io.Writer, filter *Filter, prompt string, width, height int) {
+	var lines []string
+	emitterFilter := filter.Emitter
+	if emitterFilter == "" {
+		emitterFilter = "all"
+	}
+	lines = append(lines, fmt.Sprintf("stats top - %s - emitter: %s - filter: %q",
+		m.lastTick.Format("15:04:05"), emitterFilter, filter.Text))
+	if prompt != "" {
+		lines = append(lines, prompt)
+	} else {
+		lines = append(lines, "q: quit  e: next emitter  /: filter  esc: clear filters")
+	}
+
+	var rows []*series
+	labelWidth := 0
+	for _, s := range m.sorted() {
+		if filter.match(s) {
+			rows = append(rows, s)
+			if len(s.label) > labelWidth {
+				labelWidth = len(s.label)
+			}
+		}
+	}
+	if labelWidth > width-20 {
+		labelWidth = width - 20
+	}
+
+	emitter := ""
+	for _, s := range rows {
+		if s.emitter != emitter {
+			emitter = s.emitter
+			lines = append(lines, "", "["+emitter+"]")
+		}
+		lines = append(lines, fmt.Sprintf("  %-*s %16s", labelWidth, truncate(s.label, labelWidth), m.format(s)))
+	}
+
+	if len(lines) > height {
+		lines = lines[:height]
+	}
+	sb := strings.Builder{}
+	sb.WriteString("\x1b[H\x1b[2J")
+	for idx, line := range lines {
+		if idx != 0 {
+			sb.WriteString("\r\n")
+		}
+		sb.WriteString(truncate(line, width))
+	}
+	_, _ = io.WriteString(w, sb.String())
+}
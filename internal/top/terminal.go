@@ -0,0 +1,45 @@
+package top
+
+import (
+	"golang.org/x/sys/unix"
+)
+
+// Terminal is a terminal in cbreak mode, which delivers each key press
+// without waiting for a newline, and doesn't echo it.
+type Terminal struct {
+	fd   int
+	orig unix.Termios
+}
+
+func NewTerminal(fd int) (*Terminal, error) {
+	termios, err := unix.IoctlGetTermios(fd, unix.TCGETS)
+	if err != nil {
+		return nil, err
+	}
+	t := &Terminal{
+		fd:   fd,
+		orig: *termios,
+	}
+	termios.Lflag &^= unix.ICANON | unix.ECHO
+	termios.Cc[unix.VMIN] = 1
+	termios.Cc[unix.VTIME] = 0
+	if err := unix.IoctlSetTermios(fd, unix.TCSETS, termios); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// Restore puts the terminal back into the mode it was in before NewTerminal.
+func (t *Terminal) Restore() {
+	_ = unix.IoctlSetTermios(t.fd, unix.TCSETS, &t.orig)
+}
+
+// Size returns the width and height of the terminal, or 80x24 if it can't
+// be determined.
+func Size(fd int) (int, int) {
+	ws, err := unix.IoctlGetWinsize(fd, unix.TIOCGWINSZ)
+	if err != nil || ws.Col == 0 || ws.Row == 0 {
+		return 80, 24
+	}
+	return int(ws.Col), int(ws.Row)
+}
@@ -0,0 +1,100 @@
+// Package collector is an alternative to writing directly to a statser.Pool.
+// A Collector returns the samples for a tick, and an adapter writes them to
+// the pool, so a tick's output can be tested, batched, or post-processed
+// before it is sent anywhere.
+package collector
+
+import (
+	"context"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/squizzling/stats/pkg/emitter"
+	"github.com/squizzling/stats/pkg/statser"
+)
+
+type Collector interface {
+	// Collect returns the samples for the tick.  It may return samples along
+	// with an error, if only part of the collection failed.
+	Collect(ctx context.Context, tick time.Time) ([]Sample, error)
+}
+
+type CollectorFactory func(logger *zap.Logger, opts emitter.OptProvider) Collector
+
+// Stage is a step in the pipeline between a Collector and the pool.
+type Stage func(samples []Sample) []Sample
+
+// Write writes samples to pool, stamped with tick if the pool supports it.
+func Write(pool statser.Pool, tick time.Time, samples []Sample) {
+	for _, s := range samples {
+		var c statser.Statser
+		if s.Global {
+			c = pool.Global(s.Tags...)
+		} else {
+			c = pool.Host(s.Tags...)
+		}
+		c = Describe(statser.At(c, tick), s.Kind, s.Unit)
+		switch s.Kind {
+		case KindCount:
+			c.Count(s.Name, s.Value)
+		default:
+			c.Gauge(s.Name, s.Value)
+		}
+	}
+}
+
+// Describe returns a Statser which records kind and unit with each sample if
+// s supports it, and s itself if it doesn't.  Emitters which don't use a
+// Collector can use it to describe what they write.
+func Describe(s statser.Statser, kind Kind, unit Unit) statser.Statser {
+	if ds, ok := s.(statser.DescribedStatser); ok {
+		return ds.WithDescription(string(kind), string(unit))
+	}
+	return s
+}
+
+type collectorEmitter struct {
+	logger    *zap.Logger
+	statsPool statser.Pool
+	collector Collector
+	stages    []Stage
+}
+
+// NewEmitter adapts c to an emitter.Emitter, which passes each tick's samples
+// through stages in order, and writes the result to statsPool.
+func NewEmitter(logger *zap.Logger, statsPool statser.Pool, c Collector, stages ...Stage) emitter.Emitter {
+	return &collectorEmitter{
+		logger:    logger,
+		statsPool: statsPool,
+		collector: c,
+		stages:    stages,
+	}
+}
+
+func (ce *collectorEmitter) Emit() {
+	ce.EmitTick(context.Background(), time.Now())
+}
+
+func (ce *collectorEmitter) EmitTick(ctx context.Context, tick time.Time) {
+	samples, err := ce.collector.Collect(ctx, tick)
+	if err != nil {
+		ce.logger.Warn("failed to collect", zap.Error(err))
+	}
+	for _, stage := range ce.stages {
+		samples = stage(samples)
+	}
+	Write(ce.statsPool, tick, samples)
+}
+
+// Factory adapts cf to an emitter.EmitterFactory, so collectors are registered
+// in sources.Sources alongside emitters.
+func Factory(cf CollectorFactory) emitter.EmitterFactory {
+	return func(logger *zap.Logger, statsPool statser.Pool, opts emitter.OptProvider) emitter.Emitter {
+		c := cf(logger, opts)
+		if c == nil {
+			return nil
+		}
+		return NewEmitter(logger, statsPool, c)
+	}
+}
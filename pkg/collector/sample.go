@@ -0,0 +1,57 @@
+package collector
+
+// Kind is how a sample is written to a statser.Statser.
+type Kind string
+
+const (
+	// KindGauge is a value at a point in time.
+	KindGauge = Kind("gauge")
+
+	// KindCumulative is a counter which only increases, such as the counters
+	// in /proc.  It is written as a gauge, and it is up to the receiver to
+	// calculate the rate.
+	KindCumulative = Kind("cumulative")
+
+	// KindCount is the number of events since the previous tick.
+	KindCount = Kind("count")
+)
+
+// Unit is the unit of a sample's value.  It isn't written to the pool, but is
+// available to stages which post-process samples.
+type Unit string
+
+const (
+	UnitNone         = Unit("")
+	UnitBytes        = Unit("bytes")
+	UnitSeconds      = Unit("seconds")
+	UnitMilliseconds = Unit("milliseconds")
+	UnitMicroseconds = Unit("microseconds")
+	UnitJiffies      = Unit("jiffies")
+	UnitSectors      = Unit("sectors")
+	UnitOperations   = Unit("operations")
+	UnitPercent      = Unit("percent")
+)
+
+// Sample is a single value returned by a Collector.  Tags are key/value pairs
+// in the form accepted by statser.Pool, and the host tag is added unless
+// Global is set.
+type Sample struct {
+	Name   string
+	Kind   Kind
+	Value  float64
+	Unit   Unit
+	Tags   []string
+	Global bool
+}
+
+func Gauge(name string, unit Unit, value float64, tags ...string) Sample {
+	return Sample{Name: name, Kind: KindGauge, Value: value, Unit: unit, Tags: tags}
+}
+
+func Cumulative(name string, unit Unit, value float64, tags ...string) Sample {
+	return Sample{Name: name, Kind: KindCumulative, Value: value, Unit: unit, Tags: tags}
+}
+
+func Count(name string, unit Unit, value float64, tags ...string) Sample {
+	return Sample{Name: name, Kind: KindCount, Value: value, Unit: unit, Tags: tags}
+}
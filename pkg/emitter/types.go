@@ -1,6 +1,9 @@
 package emitter
 
 import (
+	"context"
+	"time"
+
 	"go.uber.org/zap"
 
 	"github.com/squizzling/stats/pkg/statser"
@@ -12,6 +15,32 @@ type Emitter interface {
 	Emit()
 }
 
+// TickEmitter is implemented by an Emitter which wants the aligned time of the
+// tick it is emitting for, and a context which is done by the next tick, so it
+// can abandon slow work.  Factories still return an Emitter, so a TickEmitter
+// must also implement Emit, which is normally EmitTick(context.Background(),
+// time.Now()).
+type TickEmitter interface {
+	EmitTick(ctx context.Context, tick time.Time)
+}
+
+type emitAdapter struct {
+	e Emitter
+}
+
+func (ea emitAdapter) EmitTick(ctx context.Context, tick time.Time) {
+	ea.e.Emit()
+}
+
+// AsTickEmitter returns e if it is a TickEmitter, otherwise it wraps e in an
+// adapter which ignores the context and tick and calls Emit.
+func AsTickEmitter(e Emitter) TickEmitter {
+	if te, ok := e.(TickEmitter); ok {
+		return te
+	}
+	return emitAdapter{e}
+}
+
 type OptProvider interface {
 	Get(name string) interface{}
 }
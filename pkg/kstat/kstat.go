@@ -0,0 +1,176 @@
+// Package kstat parses the kstat files exposed by the SPL under
+// /proc/spl/kstat.  It has no dependencies outside the standard library, and
+// reports problems as errors rather than logging them.
+package kstat
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"strconv"
+)
+
+func parseHeader(hdr []byte) (*Kstat, error) {
+	// 6 1 0x01 91 4368 4175294187 21581160000870141
+	/*
+	   ksp->ks_kid, ksp->ks_type, ksp->ks_flags,
+	   ksp->ks_ndata, (int)ksp->ks_data_size,
+	   ksp->ks_crtime, ksp->ks_snaptime);
+	*/
+
+	parts := bytes.Split(hdr, []byte{' '})
+	if len(parts) != 7 {
+		return nil, fmt.Errorf("header has %d fields, expected 7", len(parts))
+	}
+
+	id, err := strconv.ParseInt(string(parts[0]), 0, 32)
+	if err != nil {
+		return nil, fmt.Errorf("kid: %v", err)
+	}
+
+	t, err := strconv.ParseInt(string(parts[1]), 0, 8)
+	if err != nil {
+		return nil, fmt.Errorf("ks_type: %v", err)
+	}
+
+	f, err := strconv.ParseInt(string(parts[2]), 0, 8)
+	if err != nil {
+		return nil, fmt.Errorf("ks_flags: %v", err)
+	}
+
+	ndata, err := strconv.ParseUint(string(parts[3]), 0, 32)
+	if err != nil {
+		return nil, fmt.Errorf("ks_ndata: %v", err)
+	}
+
+	datasize, err := strconv.ParseUint(string(parts[4]), 0, 32)
+	if err != nil {
+		return nil, fmt.Errorf("ks_data_size: %v", err)
+	}
+
+	crtime, err := strconv.ParseUint(string(parts[5]), 0, 64)
+	if err != nil {
+		return nil, fmt.Errorf("ks_crtime: %v", err)
+	}
+
+	snaptime, err := strconv.ParseUint(string(parts[6]), 0, 64)
+	if err != nil {
+		return nil, fmt.Errorf("ks_snaptime: %v", err)
+	}
+
+	ks := &Kstat{
+		Id:           int(id),
+		Type:         Type(t),
+		Flags:        Flag(f),
+		RecordCount:  uint(ndata),
+		DataSize:     uint(datasize),
+		CreationTime: crtime,
+		SnapTime:     snaptime,
+		UValues:      make(map[string]uint64, ndata),
+		SValues:      make(map[string]int64, ndata),
+	}
+	return ks, nil
+}
+
+func parseNamedLine(kst *Kstat, line []byte) error {
+	fields := bytes.Fields(line)
+	if len(fields) == 0 {
+		return nil
+	}
+	if len(fields) < 3 {
+		return fmt.Errorf("malformed line %q", line)
+	}
+	name := string(fields[0])
+
+	dt, err := strconv.ParseUint(string(fields[1]), 0, 8)
+	if err != nil {
+		return fmt.Errorf("%s: data type: %v", name, err)
+	}
+	dataType := Data(dt)
+	value := string(fields[2])
+
+	switch dataType {
+	case KsdUint64, KsdUint32, KsdUlong:
+		u, err := strconv.ParseUint(value, 0, 64)
+		if err != nil {
+			return fmt.Errorf("%s: %v", name, err)
+		}
+		kst.UValues[name] = u
+	case KsdInt64, KsdInt32, KsdLong:
+		s, err := strconv.ParseInt(value, 0, 64)
+		if err != nil {
+			return fmt.Errorf("%s: %v", name, err)
+		}
+		kst.SValues[name] = s
+	case KsdChar, KsdString:
+		// not numeric, and nothing consumes them
+	default:
+		return fmt.Errorf("%s: unknown data type %d", name, dataType)
+	}
+	return nil
+}
+
+func parseNamed(lines [][]byte, kst *Kstat) (*Kstat, error) {
+	if len(lines) < 2 {
+		return nil, fmt.Errorf("missing named header")
+	}
+	for _, line := range lines[2:] {
+		if err := parseNamedLine(kst, line); err != nil {
+			kst.Skipped = append(kst.Skipped, err)
+		}
+	}
+	return kst, nil
+}
+
+func parseIo(lines [][]byte, kst *Kstat) (*Kstat, error) {
+	if len(lines) != 4 {
+		return nil, fmt.Errorf("io kstat has %d lines, expected 4", len(lines))
+	}
+
+	headers := bytes.Fields(lines[1])
+	values := bytes.Fields(lines[2])
+	if len(headers) != len(values) {
+		return nil, fmt.Errorf("io kstat has %d headers and %d values", len(headers), len(values))
+	}
+
+	for i, header := range headers {
+		value, err := strconv.ParseUint(string(values[i]), 0, 64)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", header, err)
+		}
+		kst.UValues[string(header)] = value
+	}
+	return kst, nil
+}
+
+func ParseKstat(data []byte) (*Kstat, error) {
+	lines := bytes.Split(data, []byte{'\n'})
+	if len(lines) == 0 || len(lines[0]) == 0 {
+		return nil, fmt.Errorf("empty kstat")
+	}
+
+	kst, err := parseHeader(lines[0])
+	if err != nil {
+		return nil, err
+	}
+	switch kst.Type {
+	case KstNamed:
+		return parseNamed(lines, kst)
+	case KstIo:
+		return parseIo(lines, kst)
+	default:
+		return nil, fmt.Errorf("unsupported kstat type %d", kst.Type)
+	}
+}
+
+func ReadKstat(filename string) (*Kstat, error) {
+	data, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	kst, err := ParseKstat(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", filename, err)
+	}
+	return kst, nil
+}
@@ -0,0 +1,58 @@
+package kstat
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestReadKstatNamed(t *testing.T) {
+	kst, err := ReadKstat(filepath.Join("testdata", "arcstats"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if kst.Id != 6 || kst.Type != KstNamed || kst.Flags != KsfVirtual || kst.RecordCount != 6 || kst.SnapTime != 21581160000870141 {
+		t.Errorf("got header %+v", kst)
+	}
+	expectedU := map[string]uint64{"hits": 2436174, "misses": 90218}
+	if !reflect.DeepEqual(kst.UValues, expectedU) {
+		t.Errorf("got unsigned %v, expected %v", kst.UValues, expectedU)
+	}
+	expectedS := map[string]int64{"arc_meta_min": -16777216}
+	if !reflect.DeepEqual(kst.SValues, expectedS) {
+		t.Errorf("got signed %v, expected %v", kst.SValues, expectedS)
+	}
+	// bad_value and bad_type are skipped without losing the rest.
+	if len(kst.Skipped) != 2 {
+		t.Errorf("got skipped %v, expected 2", kst.Skipped)
+	}
+}
+
+func TestReadKstatIo(t *testing.T) {
+	kst, err := ReadKstat(filepath.Join("testdata", "io"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if kst.Type != KstIo {
+		t.Errorf("got type %d, expected %d", kst.Type, KstIo)
+	}
+	if len(kst.UValues) != 12 || kst.UValues["nread"] != 1187328 || kst.UValues["writes"] != 44 {
+		t.Errorf("got %v", kst.UValues)
+	}
+}
+
+func TestParseKstatMalformed(t *testing.T) {
+	for _, input := range []string{
+		"",
+		"6 1 0x01 6 1632 4175294187",
+		"x 1 0x01 6 1632 4175294187 21581160000870141",
+		"6 1 0x01 6 1632 4175294187 21581160000870141",
+		"6 0 0x01 6 1632 4175294187 21581160000870141\n",
+		"10 3 0x00 1 80 1 2\nnread nwritten\n1\n",
+		"10 3 0x00 1 80 1 2\nnread\nx\n",
+	} {
+		if _, err := ParseKstat([]byte(input)); err == nil {
+			t.Errorf("%q: expected an error", input)
+		}
+	}
+}
@@ -47,4 +47,8 @@ type Kstat struct { // struct kstat_s
 
 	UValues map[string]uint64
 	SValues map[string]int64
+
+	// Skipped records why each named value which couldn't be parsed was left
+	// out.
+	Skipped []error
 }
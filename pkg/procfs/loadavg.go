@@ -0,0 +1,87 @@
+package procfs
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+)
+
+const (
+	LoadAvgPath = "/proc/loadavg"
+	UptimePath  = "/proc/uptime"
+)
+
+// LoadAvg is the content of /proc/loadavg.
+type LoadAvg struct {
+	Load1    float64
+	Load5    float64
+	Load15   float64
+	Runnable uint64 // currently runnable scheduling entities
+	Entities uint64 // scheduling entities which exist
+	LastPID  uint64
+}
+
+func ReadLoadAvg(filename string) (*LoadAvg, error) {
+	data, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	return ParseLoadAvg(data)
+}
+
+func ParseLoadAvg(data []byte) (*LoadAvg, error) {
+	fields := bytes.Fields(data)
+	if len(fields) < 5 {
+		return nil, fmt.Errorf("expected 5 fields, found %d", len(fields))
+	}
+	loads, err := parseFloat64s(fields[:3])
+	if err != nil {
+		return nil, err
+	}
+	slash := bytes.IndexByte(fields[3], '/')
+	if slash == -1 {
+		return nil, fmt.Errorf("malformed scheduling entities %q", fields[3])
+	}
+	entities, err := parseUint64s([][]byte{fields[3][:slash], fields[3][slash+1:], fields[4]}, 3)
+	if err != nil {
+		return nil, err
+	}
+	return &LoadAvg{
+		Load1:    loads[0],
+		Load5:    loads[1],
+		Load15:   loads[2],
+		Runnable: entities[0],
+		Entities: entities[1],
+		LastPID:  entities[2],
+	}, nil
+}
+
+// Uptime is the content of /proc/uptime, in seconds.  Idle is summed across
+// every CPU, so it can be larger than Uptime.
+type Uptime struct {
+	Uptime float64
+	Idle   float64
+}
+
+func ReadUptime(filename string) (*Uptime, error) {
+	data, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	return ParseUptime(data)
+}
+
+func ParseUptime(data []byte) (*Uptime, error) {
+	fields := bytes.Fields(data)
+	if len(fields) < 2 {
+		return nil, fmt.Errorf("expected 2 fields, found %d", len(fields))
+	}
+	values, err := parseFloat64s(fields[:2])
+	if err != nil {
+		return nil, err
+	}
+	return &Uptime{
+		Uptime: values[0],
+		Idle:   values[1],
+	}, nil
+}
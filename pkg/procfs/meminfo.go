@@ -0,0 +1,49 @@
+package procfs
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"strconv"
+)
+
+const MemInfoPath = "/proc/meminfo"
+
+// MemInfo holds every value from /proc/meminfo, keyed by name.  Values with a
+// kB unit are converted to bytes.
+type MemInfo struct {
+	Values map[string]int64
+}
+
+func ReadMemInfo(filename string) (*MemInfo, error) {
+	data, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	return ParseMemInfo(data)
+}
+
+func ParseMemInfo(data []byte) (*MemInfo, error) {
+	mi := &MemInfo{
+		Values: make(map[string]int64),
+	}
+	for _, line := range bytes.Split(data, []byte{'\n'}) {
+		fields := bytes.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+		if len(fields) < 2 {
+			return nil, fmt.Errorf("malformed line %q", line)
+		}
+		name := string(bytes.TrimSuffix(fields[0], []byte{':'}))
+		value, err := strconv.ParseInt(string(fields[1]), 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", name, err)
+		}
+		if len(fields) > 2 && string(fields[2]) == "kB" {
+			value *= 1024
+		}
+		mi.Values[name] = value
+	}
+	return mi, nil
+}
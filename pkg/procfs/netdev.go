@@ -0,0 +1,87 @@
+package procfs
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+)
+
+const NetDevPath = "/proc/net/dev"
+
+// NetDevInterface is a single interface from /proc/net/dev.
+type NetDevInterface struct {
+	Name         string
+	RxBytes      uint64
+	RxPackets    uint64
+	RxErrors     uint64
+	RxDropped    uint64
+	RxOverrun    uint64
+	RxFrame      uint64
+	RxCompressed uint64
+	RxMulticast  uint64
+	TxBytes      uint64
+	TxPackets    uint64
+	TxErrors     uint64
+	TxDropped    uint64
+	TxOverrun    uint64
+	TxCollisions uint64
+	TxCarrier    uint64
+	TxCompressed uint64
+}
+
+// ReadNetDev reads a net/dev file, which is /proc/net/dev for the current
+// network namespace, or /proc/<pid>/net/dev for the namespace of a process.
+func ReadNetDev(filename string) ([]*NetDevInterface, error) {
+	data, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	return ParseNetDev(data)
+}
+
+func ParseNetDev(data []byte) ([]*NetDevInterface, error) {
+	var ifaces []*NetDevInterface
+	for _, line := range bytes.Split(data, []byte{'\n'}) {
+		// The two header lines are the only ones with a '|'
+		if len(bytes.TrimSpace(line)) == 0 || bytes.IndexByte(line, '|') != -1 {
+			continue
+		}
+		iface, err := parseNetDevInterface(line)
+		if err != nil {
+			return nil, err
+		}
+		ifaces = append(ifaces, iface)
+	}
+	return ifaces, nil
+}
+
+func parseNetDevInterface(line []byte) (*NetDevInterface, error) {
+	colon := bytes.IndexByte(line, ':')
+	if colon == -1 {
+		return nil, fmt.Errorf("malformed line %q", line)
+	}
+	name := string(bytes.TrimSpace(line[:colon]))
+	values, err := parseUint64s(bytes.Fields(line[colon+1:]), 16)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", name, err)
+	}
+	return &NetDevInterface{
+		Name:         name,
+		RxBytes:      values[0],
+		RxPackets:    values[1],
+		RxErrors:     values[2],
+		RxDropped:    values[3],
+		RxOverrun:    values[4],
+		RxFrame:      values[5],
+		RxCompressed: values[6],
+		RxMulticast:  values[7],
+		TxBytes:      values[8],
+		TxPackets:    values[9],
+		TxErrors:     values[10],
+		TxDropped:    values[11],
+		TxOverrun:    values[12],
+		TxCollisions: values[13],
+		TxCarrier:    values[14],
+		TxCompressed: values[15],
+	}, nil
+}
@@ -0,0 +1,112 @@
+package procfs
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"strconv"
+)
+
+const (
+	NetSNMPPath  = "/proc/net/snmp"
+	NetSNMP6Path = "/proc/net/snmp6"
+	NetStatPath  = "/proc/net/netstat"
+)
+
+// NetStat holds the protocol counters from /proc/net/snmp, /proc/net/snmp6,
+// or /proc/net/netstat, keyed by protocol and then by counter name, such as
+// Tcp and RetransSegs.  Some values, such as Tcp MaxConn, can be negative.
+type NetStat map[string]map[string]int64
+
+// Merge adds every counter in other to ns.
+func (ns NetStat) Merge(other NetStat) {
+	for protocol, counters := range other {
+		if ns[protocol] == nil {
+			ns[protocol] = make(map[string]int64)
+		}
+		for name, value := range counters {
+			ns[protocol][name] = value
+		}
+	}
+}
+
+// ReadNetStat reads a file in the format of /proc/net/snmp or
+// /proc/net/netstat, which may be in the /proc/<pid>/net of a process to read
+// the counters of its network namespace.
+func ReadNetStat(filename string) (NetStat, error) {
+	data, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	return ParseNetStat(data)
+}
+
+// ParseNetStat parses pairs of lines, the first naming the counters for a
+// protocol and the second holding their values.
+func ParseNetStat(data []byte) (NetStat, error) {
+	ns := make(NetStat)
+	lines := bytes.Split(bytes.TrimSpace(data), []byte{'\n'})
+	if len(lines)%2 != 0 {
+		return nil, fmt.Errorf("expected pairs of lines, found %d lines", len(lines))
+	}
+	for i := 0; i < len(lines); i += 2 {
+		names := bytes.Fields(lines[i])
+		values := bytes.Fields(lines[i+1])
+		if len(names) == 0 || len(values) == 0 || !bytes.Equal(names[0], values[0]) {
+			return nil, fmt.Errorf("mismatched lines %q and %q", names, values)
+		}
+		if len(names) != len(values) {
+			return nil, fmt.Errorf("%s expected %d values, found %d", names[0], len(names)-1, len(values)-1)
+		}
+		protocol := string(bytes.TrimSuffix(names[0], []byte{':'}))
+		counters := make(map[string]int64)
+		for j := 1; j < len(names); j++ {
+			v, err := strconv.ParseInt(string(values[j]), 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("%s %s: %v", protocol, names[j], err)
+			}
+			counters[string(names[j])] = v
+		}
+		ns[protocol] = counters
+	}
+	return ns, nil
+}
+
+// ReadNetStat6 reads a file in the format of /proc/net/snmp6.
+func ReadNetStat6(filename string) (NetStat, error) {
+	data, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	return ParseNetStat6(data)
+}
+
+// ParseNetStat6 parses a line per counter, where the name is prefixed by the
+// protocol, such as Ip6InReceives.  The protocol is everything up to the
+// first 6, so that name is Ip6 and InReceives.
+func ParseNetStat6(data []byte) (NetStat, error) {
+	ns := make(NetStat)
+	for _, line := range bytes.Split(data, []byte{'\n'}) {
+		fields := bytes.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+		if len(fields) != 2 {
+			return nil, fmt.Errorf("malformed line %q", line)
+		}
+		six := bytes.IndexByte(fields[0], '6')
+		if six == -1 {
+			return nil, fmt.Errorf("%s: no protocol", fields[0])
+		}
+		protocol, name := string(fields[0][:six+1]), string(fields[0][six+1:])
+		v, err := strconv.ParseInt(string(fields[1]), 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", fields[0], err)
+		}
+		if ns[protocol] == nil {
+			ns[protocol] = make(map[string]int64)
+		}
+		ns[protocol][name] = v
+	}
+	return ns, nil
+}
@@ -0,0 +1,51 @@
+package procfs
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// parseInt64s parses exactly count integers from fields.
+func parseInt64s(fields [][]byte, count int) ([]int64, error) {
+	if len(fields) < count {
+		return nil, fmt.Errorf("expected %d fields, found %d", count, len(fields))
+	}
+	values := make([]int64, count)
+	for i := range values {
+		v, err := strconv.ParseInt(string(fields[i]), 10, 64)
+		if err != nil {
+			return nil, err
+		}
+		values[i] = v
+	}
+	return values, nil
+}
+
+// parseUint64s parses exactly count unsigned integers from fields.
+func parseUint64s(fields [][]byte, count int) ([]uint64, error) {
+	if len(fields) < count {
+		return nil, fmt.Errorf("expected %d fields, found %d", count, len(fields))
+	}
+	values := make([]uint64, count)
+	for i := range values {
+		v, err := strconv.ParseUint(string(fields[i]), 10, 64)
+		if err != nil {
+			return nil, err
+		}
+		values[i] = v
+	}
+	return values, nil
+}
+
+// parseFloat64s parses every field as a float.
+func parseFloat64s(fields [][]byte) ([]float64, error) {
+	values := make([]float64, len(fields))
+	for i, field := range fields {
+		v, err := strconv.ParseFloat(string(field), 64)
+		if err != nil {
+			return nil, err
+		}
+		values[i] = v
+	}
+	return values, nil
+}
@@ -0,0 +1,88 @@
+package procfs
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"strconv"
+)
+
+const PressurePath = "/proc/pressure"
+
+// PressureResources are the resources with pressure stall information, named
+// as they are in /proc/pressure and in cgroup v2 <resource>.pressure files.
+var PressureResources = []string{"cpu", "memory", "io", "irq"}
+
+// PressureStall is one line of a pressure file.  The averages are the
+// percentage of time stalled over the last 10, 60, and 300 seconds, and Total
+// is the cumulative time stalled in microseconds.
+type PressureStall struct {
+	Avg10  float64
+	Avg60  float64
+	Avg300 float64
+	Total  uint64
+}
+
+// Pressure is the pressure stall information for a resource.  Some is the
+// time at least one task was stalled, and Full is the time all non-idle tasks
+// were stalled.  Either may be nil, as cpu only has some on older kernels,
+// and irq only has full.
+type Pressure struct {
+	Some *PressureStall
+	Full *PressureStall
+}
+
+func ReadPressure(filename string) (*Pressure, error) {
+	data, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	return ParsePressure(data)
+}
+
+func ParsePressure(data []byte) (*Pressure, error) {
+	p := &Pressure{}
+	for _, line := range bytes.Split(data, []byte{'\n'}) {
+		fields := bytes.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+		stall, err := parsePressureStall(fields[1:])
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", fields[0], err)
+		}
+		switch string(fields[0]) {
+		case "some":
+			p.Some = stall
+		case "full":
+			p.Full = stall
+		}
+	}
+	return p, nil
+}
+
+func parsePressureStall(fields [][]byte) (*PressureStall, error) {
+	stall := &PressureStall{}
+	for _, field := range fields {
+		eq := bytes.IndexByte(field, '=')
+		if eq == -1 {
+			return nil, fmt.Errorf("malformed field %q", field)
+		}
+		key, value := string(field[:eq]), string(field[eq+1:])
+		var err error
+		switch key {
+		case "avg10":
+			stall.Avg10, err = strconv.ParseFloat(value, 64)
+		case "avg60":
+			stall.Avg60, err = strconv.ParseFloat(value, 64)
+		case "avg300":
+			stall.Avg300, err = strconv.ParseFloat(value, 64)
+		case "total":
+			stall.Total, err = strconv.ParseUint(value, 10, 64)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", key, err)
+		}
+	}
+	return stall, nil
+}
@@ -0,0 +1,101 @@
+package procfs
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestReadStat(t *testing.T) {
+	s, err := ReadStat(filepath.Join("testdata", "stat"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectedTotal := &CPUStat{10132153, 290696, 3084719, 46828483, 16683, 0, 25195, 0, 175628, 0}
+	if !reflect.DeepEqual(s.CPUTotal, expectedTotal) {
+		t.Errorf("got total %+v, expected %+v", s.CPUTotal, expectedTotal)
+	}
+	if len(s.CPUs) != 2 || s.CPUs[0] == nil || s.CPUs[1] == nil {
+		t.Errorf("got cpus %+v, expected 0 and 1", s.CPUs)
+	} else if s.CPUs[1].Idle != 13000000 {
+		t.Errorf("got cpu1 idle %d, expected 13000000", s.CPUs[1].Idle)
+	}
+	// cpu2 and cpux are malformed, and skipped without losing the rest.
+	if len(s.Skipped) != 2 {
+		t.Errorf("got skipped %v, expected 2", s.Skipped)
+	}
+	if s.Interrupts != 199292939 || s.ContextSwitches != 275213394 || s.BootTime != 1600000000 {
+		t.Errorf("got intr %d ctxt %d btime %d", s.Interrupts, s.ContextSwitches, s.BootTime)
+	}
+	if s.Forks != 1234567 || s.ProcsRunning != 2 || s.ProcsBlocked != 1 {
+		t.Errorf("got processes %d running %d blocked %d", s.Forks, s.ProcsRunning, s.ProcsBlocked)
+	}
+	expectedSoftIRQ := &SoftIRQStat{72806047, 1, 23806004, 154, 2003530, 1001, 0, 101, 22981000, 2, 24013254}
+	if !reflect.DeepEqual(s.SoftIRQ, expectedSoftIRQ) {
+		t.Errorf("got softirq %+v, expected %+v", s.SoftIRQ, expectedSoftIRQ)
+	}
+}
+
+func TestParseStatMalformed(t *testing.T) {
+	for _, input := range []string{
+		"ctxt x",
+		"btime",
+		"softirq 1 2 3",
+	} {
+		if _, err := ParseStat([]byte(input)); err == nil {
+			t.Errorf("%q: expected an error", input)
+		}
+	}
+}
+
+func TestReadMemInfo(t *testing.T) {
+	mi, err := ReadMemInfo(filepath.Join("testdata", "meminfo"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := map[string]int64{
+		"MemTotal":        16316412 * 1024,
+		"MemFree":         1234567 * 1024,
+		"HugePages_Total": 0,
+		"Hugepagesize":    2048 * 1024,
+	}
+	if !reflect.DeepEqual(mi.Values, expected) {
+		t.Errorf("got %v, expected %v", mi.Values, expected)
+	}
+	if _, err := ParseMemInfo([]byte("MemTotal: x kB")); err == nil {
+		t.Errorf("expected an error for a malformed value")
+	}
+}
+
+func TestReadLoadAvg(t *testing.T) {
+	la, err := ReadLoadAvg(filepath.Join("testdata", "loadavg"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := &LoadAvg{Load1: 0.52, Load5: 0.58, Load15: 0.59, Runnable: 2, Entities: 1234, LastPID: 56789}
+	if !reflect.DeepEqual(la, expected) {
+		t.Errorf("got %+v, expected %+v", la, expected)
+	}
+	for _, input := range []string{"", "1 2 3 4 5", "1 2 x 2/3 4"} {
+		if _, err := ParseLoadAvg([]byte(input)); err == nil {
+			t.Errorf("%q: expected an error", input)
+		}
+	}
+}
+
+func TestReadNetDev(t *testing.T) {
+	ifaces, err := ReadNetDev(filepath.Join("testdata", "net_dev"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ifaces) != 2 {
+		t.Fatalf("got %d interfaces, expected 2", len(ifaces))
+	}
+	expected := &NetDevInterface{"eth0", 1215645, 2751, 1, 2, 3, 4, 5, 6, 1782404, 4388, 7, 8, 9, 10, 11, 12}
+	if !reflect.DeepEqual(ifaces[1], expected) {
+		t.Errorf("got %+v, expected %+v", ifaces[1], expected)
+	}
+	if _, err := ParseNetDev([]byte("eth0 1 2 3")); err == nil {
+		t.Errorf("expected an error for a malformed line")
+	}
+}
@@ -0,0 +1,52 @@
+package procfs
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"strconv"
+)
+
+const (
+	SockStatPath  = "/proc/net/sockstat"
+	SockStat6Path = "/proc/net/sockstat6"
+)
+
+// SockStat holds the socket counts from /proc/net/sockstat or sockstat6,
+// keyed by protocol and then by name, such as TCP and inuse.  The mem values
+// are in pages, other than FRAG memory which is in bytes.
+type SockStat map[string]map[string]int64
+
+func ReadSockStat(filename string) (SockStat, error) {
+	data, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	return ParseSockStat(data)
+}
+
+// ParseSockStat parses lines of a protocol followed by pairs of names and
+// values.
+func ParseSockStat(data []byte) (SockStat, error) {
+	ss := make(SockStat)
+	for _, line := range bytes.Split(data, []byte{'\n'}) {
+		fields := bytes.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+		if len(fields)%2 != 1 {
+			return nil, fmt.Errorf("malformed line %q", line)
+		}
+		protocol := string(bytes.TrimSuffix(fields[0], []byte{':'}))
+		values := make(map[string]int64)
+		for i := 1; i < len(fields); i += 2 {
+			v, err := strconv.ParseInt(string(fields[i+1]), 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("%s %s: %v", protocol, fields[i], err)
+			}
+			values[string(fields[i])] = v
+		}
+		ss[protocol] = values
+	}
+	return ss, nil
+}
@@ -0,0 +1,178 @@
+// Package procfs parses files from /proc into typed structures.  It has no
+// dependencies outside the standard library, and reports problems as errors
+// rather than logging them.
+package procfs
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"strconv"
+)
+
+const StatPath = "/proc/stat"
+
+// CPUStat is the time spent in each mode by a CPU, in USER_HZ.
+type CPUStat struct {
+	User      int64
+	Nice      int64
+	System    int64
+	Idle      int64
+	IoWait    int64
+	Irq       int64
+	SoftIrq   int64
+	Steal     int64
+	Guest     int64
+	GuestNice int64
+}
+
+// SoftIRQStat is the number of softirqs of each type serviced since boot,
+// summed across all CPUs.
+type SoftIRQStat struct {
+	Total   uint64
+	Hi      uint64
+	Timer   uint64
+	NetTx   uint64
+	NetRx   uint64
+	Block   uint64
+	IRQPoll uint64
+	Tasklet uint64
+	Sched   uint64
+	HRTimer uint64
+	RCU     uint64
+}
+
+// Stat is the contents of /proc/stat.  CPU lines which can't be parsed are
+// left out, and the reason is recorded in Skipped.
+type Stat struct {
+	CPUTotal *CPUStat
+	CPUs     map[int]*CPUStat
+	Skipped  []error
+
+	Interrupts      uint64 // total interrupts serviced since boot
+	ContextSwitches uint64
+	BootTime        int64 // seconds since the epoch
+	Forks           uint64
+	ProcsRunning    uint64
+	ProcsBlocked    uint64
+	SoftIRQ         *SoftIRQStat
+}
+
+func ReadStat(filename string) (*Stat, error) {
+	data, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	return ParseStat(data)
+}
+
+func ParseStat(data []byte) (*Stat, error) {
+	s := &Stat{
+		CPUs: make(map[int]*CPUStat),
+	}
+	for _, line := range bytes.Split(data, []byte{'\n'}) {
+		fields := bytes.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+		statType := string(fields[0])
+
+		if bytes.HasPrefix(fields[0], []byte("cpu")) {
+			if err := s.parseCPULine(statType, fields[1:]); err != nil {
+				s.Skipped = append(s.Skipped, err)
+			}
+			continue
+		}
+
+		var err error
+		switch statType {
+		case "intr":
+			// Only the total, the per-interrupt counts are in /proc/interrupts.
+			err = parseUint64(fields[1:], &s.Interrupts)
+		case "ctxt":
+			err = parseUint64(fields[1:], &s.ContextSwitches)
+		case "btime":
+			var values []int64
+			if values, err = parseInt64s(fields[1:], 1); err == nil {
+				s.BootTime = values[0]
+			}
+		case "processes":
+			err = parseUint64(fields[1:], &s.Forks)
+		case "procs_running":
+			err = parseUint64(fields[1:], &s.ProcsRunning)
+		case "procs_blocked":
+			err = parseUint64(fields[1:], &s.ProcsBlocked)
+		case "softirq":
+			s.SoftIRQ, err = parseSoftIRQStat(fields[1:])
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", statType, err)
+		}
+	}
+	return s, nil
+}
+
+func (s *Stat) parseCPULine(statType string, fields [][]byte) error {
+	cpu, err := parseCPUStat(fields)
+	if err != nil {
+		return fmt.Errorf("%s: %v", statType, err)
+	}
+	if statType == "cpu" {
+		s.CPUTotal = cpu
+		return nil
+	}
+	cpuId, err := strconv.Atoi(statType[3:])
+	if err != nil {
+		return fmt.Errorf("%s: invalid cpu number: %v", statType, err)
+	}
+	s.CPUs[cpuId] = cpu
+	return nil
+}
+
+func parseUint64(fields [][]byte, value *uint64) error {
+	values, err := parseUint64s(fields, 1)
+	if err != nil {
+		return err
+	}
+	*value = values[0]
+	return nil
+}
+
+func parseSoftIRQStat(fields [][]byte) (*SoftIRQStat, error) {
+	values, err := parseUint64s(fields, 11)
+	if err != nil {
+		return nil, err
+	}
+	return &SoftIRQStat{
+		Total:   values[0],
+		Hi:      values[1],
+		Timer:   values[2],
+		NetTx:   values[3],
+		NetRx:   values[4],
+		Block:   values[5],
+		IRQPoll: values[6],
+		Tasklet: values[7],
+		Sched:   values[8],
+		HRTimer: values[9],
+		RCU:     values[10],
+	}, nil
+}
+
+func parseCPUStat(fields [][]byte) (*CPUStat, error) {
+	values, err := parseInt64s(fields, 10)
+	if err != nil {
+		return nil, err
+	}
+	return &CPUStat{
+		User:      values[0],
+		Nice:      values[1],
+		System:    values[2],
+		Idle:      values[3],
+		IoWait:    values[4],
+		Irq:       values[5],
+		SoftIrq:   values[6],
+		Steal:     values[7],
+		Guest:     values[8],
+		GuestNice: values[9],
+	}, nil
+}
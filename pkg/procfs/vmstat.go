@@ -0,0 +1,45 @@
+package procfs
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"strconv"
+)
+
+const VmStatPath = "/proc/vmstat"
+
+// VmStat holds every value from /proc/vmstat, keyed by name.
+type VmStat struct {
+	Values map[string]uint64
+}
+
+func ReadVmStat(filename string) (*VmStat, error) {
+	data, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	return ParseVmStat(data)
+}
+
+func ParseVmStat(data []byte) (*VmStat, error) {
+	vs := &VmStat{
+		Values: make(map[string]uint64),
+	}
+	for _, line := range bytes.Split(data, []byte{'\n'}) {
+		fields := bytes.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+		if len(fields) != 2 {
+			return nil, fmt.Errorf("malformed line %q", line)
+		}
+		name := string(fields[0])
+		value, err := strconv.ParseUint(string(fields[1]), 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", name, err)
+		}
+		vs.Values[name] = value
+	}
+	return vs, nil
+}
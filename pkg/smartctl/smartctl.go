@@ -0,0 +1,193 @@
+// Package smartctl parses the output of smartctl.  It has no dependencies
+// outside the standard library, and reports problems as errors rather than
+// logging them.
+package smartctl
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var scanFormat = regexp.MustCompile("^(/[0-9a-zA-Z/_\\-]+).*$")
+
+// ParseScan returns the device paths from the output of `smartctl --scan`.
+func ParseScan(data []byte) []string {
+	var found []string
+	for _, line := range bytes.Split(data, []byte{'\n'}) {
+		if matches := scanFormat.FindAllSubmatch(line, -1); matches != nil {
+			found = append(found, string(matches[0][1]))
+		}
+	}
+	return found
+}
+
+type Attribute struct {
+	ID            int64
+	Name          string
+	Flag          string
+	Value         int64
+	Worst         int64
+	Threshold     int64
+	PreFail       bool
+	UpdatedAlways bool
+	WhenFailed    string
+	RawValue      int64
+	RawString     string
+}
+
+// Data is the output of `smartctl --attributes --info`.  Information is the
+// key/value pairs from the information section.  Attributes which can't be
+// parsed, such as those with a vendor specific raw value, are left out, and
+// the reason is recorded in Skipped.
+type Data struct {
+	Information     map[string]string
+	AttributeByID   map[int64]*Attribute
+	AttributeByName map[string]*Attribute
+	Skipped         []error
+}
+
+func ParseData(data []byte) (*Data, error) {
+	d := &Data{
+		Information:     make(map[string]string),
+		AttributeByID:   make(map[int64]*Attribute),
+		AttributeByName: make(map[string]*Attribute),
+	}
+	mode := d.ignoreLine
+	for _, line := range bytes.Split(data, []byte{'\n'}) {
+		if string(line) == "=== START OF INFORMATION SECTION ===" {
+			mode = d.parseInfoLine
+		} else if bytes.HasPrefix(line, []byte("ID#")) {
+			mode = d.parseAttributeLine
+		} else if len(line) == 0 {
+			mode = d.ignoreLine
+		} else if err := mode(line); err != nil {
+			d.Skipped = append(d.Skipped, err)
+		}
+	}
+	return d, nil
+}
+
+func (d *Data) ignoreLine(line []byte) error {
+	return nil
+}
+
+func (d *Data) parseInfoLine(line []byte) error {
+	/*
+		=== START OF INFORMATION SECTION ===
+		Device Model:     ST6000DX000-1H217Z
+		Serial Number:    Z4D08518
+		LU WWN Device Id: 5 000c50 078ce307e
+		Firmware Version: CC48
+		User Capacity:    6,001,175,126,016 bytes [6.00 TB]
+		Sector Sizes:     512 bytes logical, 4096 bytes physical
+		Rotation Rate:    7200 rpm
+		Form Factor:      3.5 inches
+		Device is:        Not in smartctl database [for details use: -P showall]
+		ATA Version is:   ACS-3 T13/2161-D revision 3b
+		SATA Version is:  SATA 3.1, 6.0 Gb/s (current: 6.0 Gb/s)
+		Local Time is:    Sat Sep  5 14:57:26 2020 AEST
+		SMART support is: Available - device has SMART capability.
+		SMART support is: Enabled
+	*/
+	if split := bytes.SplitN(line, []byte{':'}, 2); len(split) == 2 {
+		d.Information[string(split[0])] = string(bytes.TrimSpace(split[1]))
+	}
+	return nil
+}
+
+var attributeFormat = regexp.MustCompile(`\s*([0-9]+)\s([^\s]+)\s+(0x[0-9a-fA-F]{4})\s+(\d+)\s+(\d+)\s+(\d+)\s+([a-zA-Z_\\-]+)\s+([a-zA-Z_\\-]+)\s+([a-zA-Z_\\-]+)\s+(.*)`)
+
+// leadingInteger parses the integer at the start of a raw value, as some
+// drives decorate them, such as "53 (0 22 0 0 0)" or "29043h+12m+04.123s".
+// Hex raw values aren't decimal, and are rejected rather than read as 0.
+func leadingInteger(s string) (int64, error) {
+	if strings.HasPrefix(s, "0x") || strings.HasPrefix(s, "0X") {
+		return 0, fmt.Errorf("hex value %q", s)
+	}
+	end := 0
+	for end < len(s) && s[end] >= '0' && s[end] <= '9' {
+		end++
+	}
+	return strconv.ParseInt(s[:end], 10, 64)
+}
+
+func (d *Data) parseAttributeLine(line []byte) error {
+	parts := attributeFormat.FindStringSubmatch(string(line))
+	if len(parts) != 11 {
+		return nil
+	}
+	a := &Attribute{
+		Name:       parts[2],
+		Flag:       parts[3],
+		WhenFailed: parts[9],
+		RawString:  strings.TrimSpace(parts[10]),
+	}
+
+	var err error
+	if a.ID, err = strconv.ParseInt(parts[1], 10, 32); err != nil {
+		return fmt.Errorf("%s: id: %v", a.Name, err)
+	}
+	if a.Value, err = strconv.ParseInt(parts[4], 10, 32); err != nil {
+		return fmt.Errorf("%s: value: %v", a.Name, err)
+	}
+	if a.Worst, err = strconv.ParseInt(parts[5], 10, 32); err != nil {
+		return fmt.Errorf("%s: worst: %v", a.Name, err)
+	}
+	if a.Threshold, err = strconv.ParseInt(parts[6], 10, 32); err != nil {
+		return fmt.Errorf("%s: threshold: %v", a.Name, err)
+	}
+	if parts[7] == "Pre-fail" {
+		a.PreFail = true
+	} else if parts[7] != "Old_age" {
+		return fmt.Errorf("%s: unknown type %s", a.Name, parts[7])
+	}
+
+	if parts[8] == "Always" {
+		a.UpdatedAlways = true
+	} else if parts[8] != "Offline" {
+		return fmt.Errorf("%s: unknown updated %s", a.Name, parts[8])
+	}
+
+	if a.RawValue, err = leadingInteger(a.RawString); err != nil {
+		return fmt.Errorf("%s: raw value: %v", a.Name, err)
+	}
+
+	d.AttributeByID[a.ID] = a
+	d.AttributeByName[a.Name] = a
+
+	/*
+	   === START OF READ SMART DATA SECTION ===
+	   SMART Attributes Data Structure revision number: 10
+	   Vendor Specific SMART Attributes with Thresholds:
+	   ID# ATTRIBUTE_NAME          FLAG     VALUE WORST THRESH TYPE      UPDATED  WHEN_FAILED RAW_VALUE
+	     1 Raw_Read_Error_Rate     0x000f   118   099   006    Pre-fail  Always       -       177049170
+	     3 Spin_Up_Time            0x0003   095   095   000    Pre-fail  Always       -       0
+	     4 Start_Stop_Count        0x0032   100   100   020    Old_age   Always       -       5
+	     5 Reallocated_Sector_Ct   0x0033   100   100   010    Pre-fail  Always       -       0
+	     7 Seek_Error_Rate         0x000f   090   060   030    Pre-fail  Always       -       966530877
+	     9 Power_On_Hours          0x0032   067   067   000    Old_age   Always       -       29043
+	    10 Spin_Retry_Count        0x0013   100   100   097    Pre-fail  Always       -       0
+	    12 Power_Cycle_Count       0x0032   100   100   020    Old_age   Always       -       5
+	   183 Runtime_Bad_Block       0x0032   100   100   000    Old_age   Always       -       0
+	   184 End-to-End_Error        0x0032   100   100   099    Old_age   Always       -       0
+	   187 Reported_Uncorrect      0x0032   100   100   000    Old_age   Always       -       0
+	   188 Command_Timeout         0x0032   100   100   000    Old_age   Always       -       0
+	   189 High_Fly_Writes         0x003a   100   100   000    Old_age   Always       -       0
+	   190 Airflow_Temperature_Cel 0x0022   047   036   045    Old_age   Always   In_the_past 53 (255 255 61 43 0)
+	   191 G-Sense_Error_Rate      0x0032   097   097   000    Old_age   Always       -       6873
+	   192 Power-Off_Retract_Count 0x0032   100   100   000    Old_age   Always       -       2
+	   193 Load_Cycle_Count        0x0032   100   100   000    Old_age   Always       -       1213
+	   194 Temperature_Celsius     0x0022   053   064   000    Old_age   Always       -       53 (0 22 0 0 0)
+	   195 Hardware_ECC_Recovered  0x001a   064   054   000    Old_age   Always       -       177049170
+	   197 Current_Pending_Sector  0x0012   100   100   000    Old_age   Always       -       0
+	   198 Offline_Uncorrectable   0x0010   100   100   000    Old_age   Offline      -       0
+	   199 UDMA_CRC_Error_Count    0x003e   200   200   000    Old_age   Always       -       0
+	   240 Head_Flying_Hours       0x0000   100   253   000    Old_age   Offline      -       29043 (190 138 0)
+	   241 Total_LBAs_Written      0x0000   100   253   000    Old_age   Offline      -       39862642689
+	   242 Total_LBAs_Read         0x0000   100   253   000    Old_age   Offline      -       7734202075417
+	*/
+	return nil
+}
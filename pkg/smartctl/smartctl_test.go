@@ -0,0 +1,63 @@
+package smartctl
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func readFixture(t *testing.T, name string) []byte {
+	data, err := ioutil.ReadFile(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+func TestParseScan(t *testing.T) {
+	found := ParseScan(readFixture(t, "scan"))
+	expected := []string{"/dev/sda", "/dev/nvme0"}
+	if !reflect.DeepEqual(found, expected) {
+		t.Errorf("got %v, expected %v", found, expected)
+	}
+}
+
+func TestParseData(t *testing.T) {
+	d, err := ParseData(readFixture(t, "attributes"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectedInfo := map[string]string{
+		"Device Model":  "ST6000DX000-1H217Z",
+		"Serial Number": "Z4D08518",
+		"User Capacity": "6,001,175,126,016 bytes [6.00 TB]",
+	}
+	if !reflect.DeepEqual(d.Information, expectedInfo) {
+		t.Errorf("got information %v, expected %v", d.Information, expectedInfo)
+	}
+
+	expected := []*Attribute{
+		{ID: 1, Name: "Raw_Read_Error_Rate", Flag: "0x000f", Value: 118, Worst: 99, Threshold: 6, PreFail: true, UpdatedAlways: true, WhenFailed: "-", RawValue: 177049170, RawString: "177049170"},
+		{ID: 9, Name: "Power_On_Hours", Flag: "0x0032", Value: 67, Worst: 67, Threshold: 0, UpdatedAlways: true, WhenFailed: "-", RawValue: 29043, RawString: "29043h+12m+04.123s"},
+		{ID: 190, Name: "Airflow_Temperature_Cel", Flag: "0x0022", Value: 47, Worst: 36, Threshold: 45, UpdatedAlways: true, WhenFailed: "In_the_past", RawValue: 53, RawString: "53 (255 255 61 43 0)"},
+		{ID: 198, Name: "Offline_Uncorrectable", Flag: "0x0010", Value: 100, Worst: 100, Threshold: 0, WhenFailed: "-", RawValue: 0, RawString: "0"},
+	}
+	if len(d.AttributeByID) != len(expected) {
+		t.Errorf("got %d attributes, expected %d", len(d.AttributeByID), len(expected))
+	}
+	for _, a := range expected {
+		if !reflect.DeepEqual(d.AttributeByID[a.ID], a) {
+			t.Errorf("%d: got %+v, expected %+v", a.ID, d.AttributeByID[a.ID], a)
+		}
+		if d.AttributeByName[a.Name] != d.AttributeByID[a.ID] {
+			t.Errorf("%s: not indexed by name", a.Name)
+		}
+	}
+
+	// The hex raw value and the unknown type are skipped without losing the
+	// rest.
+	if len(d.Skipped) != 2 {
+		t.Errorf("got skipped %v, expected 2", d.Skipped)
+	}
+}
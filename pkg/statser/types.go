@@ -1,5 +1,9 @@
 package statser
 
+import (
+	"time"
+)
+
 type Pool interface {
 	Host(tags ...string) Statser
 	Global(tags ...string) Statser
@@ -9,3 +13,27 @@ type Statser interface {
 	Gauge(metricName string, value interface{})
 	Count(metricName string, value interface{})
 }
+
+// TimestampStatser is implemented by a Statser whose backend can record when
+// a sample was taken, rather than when it was received.
+type TimestampStatser interface {
+	Statser
+	WithTimestamp(t time.Time) Statser
+}
+
+// DescribedStatser is implemented by a Statser which shows samples locally,
+// and so needs to know how to interpret them, beyond gauge or count.  kind
+// and unit are the values of a collector.Kind and collector.Unit.
+type DescribedStatser interface {
+	Statser
+	WithDescription(kind, unit string) Statser
+}
+
+// At returns a Statser which stamps samples with t if s supports timestamps,
+// and s itself if it doesn't.
+func At(s Statser, t time.Time) Statser {
+	if ts, ok := s.(TimestampStatser); ok {
+		return ts.WithTimestamp(t)
+	}
+	return s
+}
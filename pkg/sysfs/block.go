@@ -0,0 +1,111 @@
+// Package sysfs parses files from /sys into typed structures.  It has no
+// dependencies outside the standard library, and reports problems as errors
+// rather than logging them.
+package sysfs
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"path"
+	"strconv"
+)
+
+const BlockPath = "/sys/block"
+
+// BlockStatVersion is the kernel version which introduced the fields present
+// in a BlockStat.
+//
+// https://www.kernel.org/doc/html/latest/block/stat.html
+type BlockStatVersion int
+
+const (
+	BlockStatEarly = BlockStatVersion(0)
+	BlockStat4_19  = BlockStatVersion(1)
+	BlockStat5_5   = BlockStatVersion(2)
+)
+
+type BlockStat struct {
+	Name    string
+	Version BlockStatVersion
+
+	ReadIOs     uint64
+	ReadMerges  uint64
+	ReadSectors uint64
+	ReadTicks   uint64
+
+	WriteIOs     uint64
+	WriteMerges  uint64
+	WriteSectors uint64
+	WriteTicks   uint64
+
+	InFlight    uint64
+	IoTicks     uint64
+	TimeInQueue uint64
+
+	// v4.19+
+	DiscardIOs     uint64
+	DiscardMerges  uint64
+	DiscardSectors uint64
+	DiscardTicks   uint64
+
+	// v5.5+
+	FlushIOs   uint64
+	FlushTicks uint64
+}
+
+// ReadBlockStat reads /sys/block/<deviceName>/stat.
+func ReadBlockStat(deviceName string) (*BlockStat, error) {
+	data, err := ioutil.ReadFile(path.Join(BlockPath, deviceName, "stat"))
+	if err != nil {
+		return nil, err
+	}
+	return ParseBlockStat(deviceName, data)
+}
+
+func ParseBlockStat(deviceName string, data []byte) (*BlockStat, error) {
+	fields := bytes.Fields(data)
+	values := make([]uint64, len(fields))
+	for i, field := range fields {
+		v, err := strconv.ParseUint(string(field), 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", deviceName, err)
+		}
+		values[i] = v
+	}
+
+	bs := &BlockStat{
+		Name: deviceName,
+	}
+	switch {
+	case len(values) >= 17:
+		bs.Version = BlockStat5_5
+		bs.FlushIOs = values[15]
+		bs.FlushTicks = values[16]
+		fallthrough
+	case len(values) >= 15:
+		if bs.Version == BlockStatEarly {
+			bs.Version = BlockStat4_19
+		}
+		bs.DiscardIOs = values[11]
+		bs.DiscardMerges = values[12]
+		bs.DiscardSectors = values[13]
+		bs.DiscardTicks = values[14]
+		fallthrough
+	case len(values) >= 11:
+		bs.ReadIOs = values[0]
+		bs.ReadMerges = values[1]
+		bs.ReadSectors = values[2]
+		bs.ReadTicks = values[3]
+		bs.WriteIOs = values[4]
+		bs.WriteMerges = values[5]
+		bs.WriteSectors = values[6]
+		bs.WriteTicks = values[7]
+		bs.InFlight = values[8]
+		bs.IoTicks = values[9]
+		bs.TimeInQueue = values[10]
+	default:
+		return nil, fmt.Errorf("%s: expected at least 11 fields, found %d", deviceName, len(values))
+	}
+	return bs, nil
+}
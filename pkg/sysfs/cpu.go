@@ -0,0 +1,106 @@
+package sysfs
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"path"
+	"strconv"
+	"strings"
+)
+
+const CPUPath = "/sys/devices/system/cpu"
+
+// CPUTopology is where a CPU sits in the machine.  NUMANode is -1 if the
+// kernel doesn't expose it, such as when NUMA support is disabled.
+type CPUTopology struct {
+	CPU       int
+	CoreID    int
+	PackageID int
+	NUMANode  int
+}
+
+// ReadCPUTopology reads /sys/devices/system/cpu/cpu<cpu>/topology, and the
+// node link which identifies the NUMA node.
+func ReadCPUTopology(cpu int) (*CPUTopology, error) {
+	cpuPath := path.Join(CPUPath, fmt.Sprintf("cpu%d", cpu))
+	t := &CPUTopology{
+		CPU:      cpu,
+		NUMANode: -1,
+	}
+
+	var err error
+	if t.CoreID, err = readInt(path.Join(cpuPath, "topology", "core_id")); err != nil {
+		return nil, err
+	}
+	if t.PackageID, err = readInt(path.Join(cpuPath, "topology", "physical_package_id")); err != nil {
+		return nil, err
+	}
+
+	entries, err := ioutil.ReadDir(cpuPath)
+	if err != nil {
+		return nil, err
+	}
+	for _, e := range entries {
+		if !strings.HasPrefix(e.Name(), "node") {
+			continue
+		}
+		if node, err := strconv.Atoi(e.Name()[4:]); err == nil {
+			t.NUMANode = node
+			break
+		}
+	}
+	return t, nil
+}
+
+func readInt(filename string) (int, error) {
+	data, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return 0, err
+	}
+	v, err := strconv.Atoi(string(bytes.TrimSpace(data)))
+	if err != nil {
+		return 0, fmt.Errorf("%s: %v", filename, err)
+	}
+	return v, nil
+}
+
+// ReadOnlineCPUs returns the number of online CPUs.
+func ReadOnlineCPUs() (int, error) {
+	data, err := ioutil.ReadFile(path.Join(CPUPath, "online"))
+	if err != nil {
+		return 0, err
+	}
+	cpus, err := ParseCPUList(data)
+	if err != nil {
+		return 0, err
+	}
+	return len(cpus), nil
+}
+
+// ParseCPUList parses a list of CPUs in the kernel's list format, such as
+// "0-3,8,10-11".
+func ParseCPUList(data []byte) ([]int, error) {
+	var cpus []int
+	list := strings.TrimSpace(string(data))
+	if list == "" {
+		return nil, nil
+	}
+	for _, r := range strings.Split(list, ",") {
+		bounds := strings.SplitN(r, "-", 2)
+		first, err := strconv.Atoi(bounds[0])
+		if err != nil {
+			return nil, fmt.Errorf("invalid cpu list %q: %v", list, err)
+		}
+		last := first
+		if len(bounds) == 2 {
+			if last, err = strconv.Atoi(bounds[1]); err != nil {
+				return nil, fmt.Errorf("invalid cpu list %q: %v", list, err)
+			}
+		}
+		for cpu := first; cpu <= last; cpu++ {
+			cpus = append(cpus, cpu)
+		}
+	}
+	return cpus, nil
+}
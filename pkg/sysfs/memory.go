@@ -0,0 +1,140 @@
+package sysfs
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"path"
+	"strconv"
+	"strings"
+)
+
+const (
+	NodePath      = "/sys/devices/system/node"
+	HugePagesPath = "/sys/kernel/mm/hugepages"
+)
+
+// ReadNodes returns the NUMA nodes.  It returns an empty list if the kernel
+// doesn't support NUMA.
+func ReadNodes() ([]int, error) {
+	entries, err := ioutil.ReadDir(NodePath)
+	if err != nil {
+		return nil, err
+	}
+	var nodes []int
+	for _, e := range entries {
+		if !strings.HasPrefix(e.Name(), "node") {
+			continue
+		}
+		if node, err := strconv.Atoi(e.Name()[4:]); err == nil {
+			nodes = append(nodes, node)
+		}
+	}
+	return nodes, nil
+}
+
+// NodeMemInfo holds every value from a NUMA node's meminfo, keyed by name.
+// Values with a kB unit are converted to bytes.
+type NodeMemInfo struct {
+	Node   int
+	Values map[string]int64
+}
+
+// ReadNodeMemInfo reads /sys/devices/system/node/node<node>/meminfo.
+func ReadNodeMemInfo(node int) (*NodeMemInfo, error) {
+	data, err := ioutil.ReadFile(path.Join(NodePath, fmt.Sprintf("node%d", node), "meminfo"))
+	if err != nil {
+		return nil, err
+	}
+	return ParseNodeMemInfo(node, data)
+}
+
+// ParseNodeMemInfo parses a NUMA node's meminfo, which is the same as
+// /proc/meminfo with a "Node <node>" prefix on every line.
+func ParseNodeMemInfo(node int, data []byte) (*NodeMemInfo, error) {
+	nmi := &NodeMemInfo{
+		Node:   node,
+		Values: make(map[string]int64),
+	}
+	for _, line := range bytes.Split(data, []byte{'\n'}) {
+		fields := bytes.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+		if len(fields) < 4 || string(fields[0]) != "Node" {
+			return nil, fmt.Errorf("node%d: malformed line %q", node, line)
+		}
+		name := string(bytes.TrimSuffix(fields[2], []byte{':'}))
+		value, err := strconv.ParseInt(string(fields[3]), 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("node%d: %s: %v", node, name, err)
+		}
+		if len(fields) > 4 && string(fields[4]) == "kB" {
+			value *= 1024
+		}
+		nmi.Values[name] = value
+	}
+	return nmi, nil
+}
+
+// HugePagePool is the state of the pool of huge pages of one size.  The
+// counts are in pages.
+type HugePagePool struct {
+	Name       string // the size as named by the kernel, such as 2048kB
+	Size       uint64 // bytes
+	Total      uint64
+	Free       uint64
+	Reserved   uint64
+	Surplus    uint64
+	Overcommit uint64
+}
+
+// ReadHugePageSizes returns the names of the pools in /sys/kernel/mm/hugepages,
+// such as 2048kB.  It returns an empty list if the kernel doesn't support huge
+// pages.
+func ReadHugePageSizes() ([]string, error) {
+	entries, err := ioutil.ReadDir(HugePagesPath)
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	for _, e := range entries {
+		if strings.HasPrefix(e.Name(), "hugepages-") && strings.HasSuffix(e.Name(), "kB") {
+			names = append(names, strings.TrimPrefix(e.Name(), "hugepages-"))
+		}
+	}
+	return names, nil
+}
+
+// ReadHugePagePool reads the pool of huge pages named name, as returned by
+// ReadHugePageSizes.
+func ReadHugePagePool(name string) (*HugePagePool, error) {
+	dirName := "hugepages-" + name
+	sizeKiB, err := strconv.ParseUint(strings.TrimSuffix(name, "kB"), 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("%s: invalid size: %v", dirName, err)
+	}
+	pool := &HugePagePool{
+		Name: name,
+		Size: sizeKiB * 1024,
+	}
+	for _, f := range []struct {
+		file  string
+		value *uint64
+	}{
+		{"nr_hugepages", &pool.Total},
+		{"free_hugepages", &pool.Free},
+		{"resv_hugepages", &pool.Reserved},
+		{"surplus_hugepages", &pool.Surplus},
+		{"nr_overcommit_hugepages", &pool.Overcommit},
+	} {
+		data, err := ioutil.ReadFile(path.Join(HugePagesPath, dirName, f.file))
+		if err != nil {
+			return nil, err
+		}
+		if *f.value, err = strconv.ParseUint(string(bytes.TrimSpace(data)), 10, 64); err != nil {
+			return nil, fmt.Errorf("%s/%s: %v", dirName, f.file, err)
+		}
+	}
+	return pool, nil
+}
@@ -0,0 +1,111 @@
+package sysfs
+
+import (
+	"bytes"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path"
+	"strconv"
+	"syscall"
+)
+
+const NetClassPath = "/sys/class/net"
+
+// NetLink is the link state of a network interface.  Speed is -1 if the
+// driver doesn't report it, such as for virtual interfaces, and Carrier is
+// -1 if the interface is administratively down.
+type NetLink struct {
+	Name           string
+	Speed          int64 // Mbit/s
+	Duplex         string
+	MTU            int64
+	OperState      string
+	Carrier        int64
+	CarrierChanges uint64
+
+	// Master is the bond or bridge the interface is enslaved to, if any, and
+	// MasterKind is "bond", "bridge", or "other".
+	Master     string
+	MasterKind string
+}
+
+// ReadNetLink reads /sys/class/net/<name>.
+func ReadNetLink(name string) (*NetLink, error) {
+	dir := path.Join(NetClassPath, name)
+	nl := &NetLink{
+		Name: name,
+	}
+
+	var err error
+	if nl.MTU, err = readNetInt(dir, "mtu", 0); err != nil {
+		return nil, err
+	}
+	if nl.Speed, err = readNetInt(dir, "speed", -1); err != nil {
+		return nil, err
+	}
+	if nl.Carrier, err = readNetInt(dir, "carrier", -1); err != nil {
+		return nil, err
+	}
+	changes, err := readNetInt(dir, "carrier_changes", 0)
+	if err != nil {
+		return nil, err
+	}
+	nl.CarrierChanges = uint64(changes)
+	if nl.Duplex, err = readNetString(dir, "duplex", "unknown"); err != nil {
+		return nil, err
+	}
+	if nl.OperState, err = readNetString(dir, "operstate", "unknown"); err != nil {
+		return nil, err
+	}
+
+	master, err := os.Readlink(path.Join(dir, "master"))
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	if master != "" {
+		nl.Master = path.Base(master)
+		switch {
+		case exists(path.Join(NetClassPath, nl.Master, "bonding")):
+			nl.MasterKind = "bond"
+		case exists(path.Join(NetClassPath, nl.Master, "bridge")):
+			nl.MasterKind = "bridge"
+		default:
+			nl.MasterKind = "other"
+		}
+	}
+	return nl, nil
+}
+
+func exists(filename string) bool {
+	_, err := os.Stat(filename)
+	return err == nil
+}
+
+// isUnsupported reports whether err is how the kernel reports an attribute
+// which doesn't apply to the interface, or its current state.
+func isUnsupported(err error) bool {
+	return os.IsNotExist(err) || errors.Is(err, syscall.EINVAL) || errors.Is(err, syscall.EOPNOTSUPP)
+}
+
+func readNetString(dir, name, unsupported string) (string, error) {
+	data, err := ioutil.ReadFile(path.Join(dir, name))
+	if err != nil {
+		if isUnsupported(err) {
+			return unsupported, nil
+		}
+		return "", err
+	}
+	return string(bytes.TrimSpace(data)), nil
+}
+
+func readNetInt(dir, name string, unsupported int64) (int64, error) {
+	s, err := readNetString(dir, name, "")
+	if err != nil {
+		return 0, err
+	}
+	if s == "" {
+		return unsupported, nil
+	}
+	return strconv.ParseInt(s, 10, 64)
+}
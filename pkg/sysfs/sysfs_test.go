@@ -0,0 +1,104 @@
+package sysfs
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func readFixture(t *testing.T, name string) []byte {
+	data, err := ioutil.ReadFile(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+func TestParseBlockStat(t *testing.T) {
+	tests := []struct {
+		fixture  string
+		expected *BlockStat
+	}{
+		{
+			fixture: "block_stat_5.5",
+			expected: &BlockStat{
+				Name: "sda", Version: BlockStat5_5,
+				ReadIOs: 4346, ReadMerges: 1021, ReadSectors: 338466, ReadTicks: 2133,
+				WriteIOs: 3126, WriteMerges: 3309, WriteSectors: 60640, WriteTicks: 4215,
+				InFlight: 0, IoTicks: 7916, TimeInQueue: 6348,
+				DiscardIOs: 100, DiscardMerges: 0, DiscardSectors: 20480, DiscardTicks: 11,
+				FlushIOs: 50, FlushTicks: 12,
+			},
+		},
+		{
+			fixture: "block_stat_early",
+			expected: &BlockStat{
+				Name: "sda", Version: BlockStatEarly,
+				ReadIOs: 4346, ReadMerges: 1021, ReadSectors: 338466, ReadTicks: 2133,
+				WriteIOs: 3126, WriteMerges: 3309, WriteSectors: 60640, WriteTicks: 4215,
+				InFlight: 0, IoTicks: 7916, TimeInQueue: 6348,
+			},
+		},
+	}
+	for _, test := range tests {
+		bs, err := ParseBlockStat("sda", readFixture(t, test.fixture))
+		if err != nil {
+			t.Errorf("%s: %v", test.fixture, err)
+			continue
+		}
+		if !reflect.DeepEqual(bs, test.expected) {
+			t.Errorf("%s: got %+v, expected %+v", test.fixture, bs, test.expected)
+		}
+	}
+	for _, input := range []string{"", "1 2 3", "1 2 3 4 5 6 7 8 9 10 x"} {
+		if _, err := ParseBlockStat("sda", []byte(input)); err == nil {
+			t.Errorf("%q: expected an error", input)
+		}
+	}
+}
+
+func TestParseCPUList(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected []int
+		err      bool
+	}{
+		{input: "", expected: nil},
+		{input: "0\n", expected: []int{0}},
+		{input: "0-3", expected: []int{0, 1, 2, 3}},
+		{input: "0-1,4,6-7\n", expected: []int{0, 1, 4, 6, 7}},
+		{input: "x", err: true},
+		{input: "0-x", err: true},
+	}
+	for _, test := range tests {
+		cpus, err := ParseCPUList([]byte(test.input))
+		if (err != nil) != test.err {
+			t.Errorf("%q: got error %v, expected error %v", test.input, err, test.err)
+			continue
+		}
+		if !reflect.DeepEqual(cpus, test.expected) {
+			t.Errorf("%q: got %v, expected %v", test.input, cpus, test.expected)
+		}
+	}
+}
+
+func TestParseNodeMemInfo(t *testing.T) {
+	nmi, err := ParseNodeMemInfo(0, readFixture(t, "node_meminfo"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := map[string]int64{
+		"MemTotal":        16316412 * 1024,
+		"MemFree":         1234567 * 1024,
+		"HugePages_Total": 0,
+	}
+	if nmi.Node != 0 || !reflect.DeepEqual(nmi.Values, expected) {
+		t.Errorf("got node %d %v, expected node 0 %v", nmi.Node, nmi.Values, expected)
+	}
+	for _, input := range []string{"MemTotal: 1 kB", "Node 0 MemTotal: x kB"} {
+		if _, err := ParseNodeMemInfo(0, []byte(input)); err == nil {
+			t.Errorf("%q: expected an error", input)
+		}
+	}
+}